@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"crypto/fips140"
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -17,6 +18,22 @@ import (
 )
 
 func main() {
+	configFile := flag.String(
+		"config-file",
+		"",
+		"YAML or JSON file with the authenticator settings, overrides CONJUR_CONFIG_FILE",
+	)
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "explain":
+		explain(*configFile, flag.Args()[1:])
+		return
+	case "schema":
+		schema(flag.Args()[1:])
+		return
+	}
+
 	// Note: This will log even if the log level is set to "warn" or "error" since that's loaded after this
 	log.Info(log.CAKC048, authenticator.FullVersionName)
 
@@ -26,7 +43,7 @@ func main() {
 
 	var err error
 
-	config, err := config.NewConfigFromEnv()
+	config, err := config.NewConfigFromEnvAndFile(*configFile)
 	if err != nil {
 		printErrorAndExit(log.CAKC018)
 	}
@@ -74,6 +91,38 @@ func main() {
 	}
 }
 
+// explain prints the effective configuration and the source of each setting,
+// without authenticating
+func explain(configFile string, args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	format := flags.String("format", config.FormatTable, "Output format, table or json")
+	flags.Parse(args)
+
+	// Keep stdout for the configuration, e.g. to pipe it to jq
+	log.InfoLogger.SetOutput(os.Stderr)
+
+	settings, err := config.ExplainConfig(os.ReadFile, os.Getenv, configFile)
+	if err != nil {
+		printErrorAndExit(log.CAKC018)
+	}
+	if err := config.WriteEffectiveConfig(os.Stdout, settings, *format); err != nil {
+		printErrorAndExit(err.Error())
+	}
+}
+
+// schema prints the JSON schema of the settings
+func schema(args []string) {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	authnType := flags.String("authenticator", "", "authn-k8s or authn-jwt, all settings if empty")
+	flags.Parse(args)
+
+	schema, err := config.JSONSchema(*authnType)
+	if err != nil {
+		printErrorAndExit(err.Error())
+	}
+	fmt.Println(string(schema))
+}
+
 func printErrorAndExit(errorMessage string) {
 	log.Error(errorMessage)
 	os.Exit(1)
@@ -10,3 +10,19 @@ type Authenticator interface {
 	AuthenticateWithContext(ctx context.Context) error
 	GetAccessToken() access_token.AccessToken
 }
+
+// Stopper is implemented by the authenticators holding resources, such as a
+// background certificate renewal, that must be released once they are no
+// longer used
+type Stopper interface {
+	// Stop releases the resources of the authenticator, which must not be
+	// used afterwards
+	Stop()
+}
+
+// Stop releases the resources of the authenticator if it is a Stopper
+func Stop(authn Authenticator) {
+	if stopper, ok := authn.(Stopper); ok {
+		stopper.Stop()
+	}
+}
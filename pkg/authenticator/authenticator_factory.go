@@ -10,6 +10,12 @@ import (
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 )
 
+// The authenticators release their resources through Stop
+var (
+	_ Stopper = (*k8sAuthenticator.Authenticator)(nil)
+	_ Stopper = (*jwtAuthenticator.Authenticator)(nil)
+)
+
 // NewAuthenticator creates an instance of the Authenticator interface based on configured authenticator type.
 func NewAuthenticator(conf config.Configuration) (Authenticator, error) {
 	accessToken, error := file.NewAccessToken(conf.GetTokenFilePath())
@@ -8,11 +8,15 @@ import (
 	"encoding/pem"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/fullsailor/pkcs7"
 )
 
 type TestAuthServer struct {
@@ -21,10 +25,25 @@ type TestAuthServer struct {
 	CertLogPath        string
 	ExpectedTokenValue string
 	SkipWritingCSRFile bool
-	HandleLogin        func(
+	// InjectionLog is written to the cert injection log instead of the client
+	// certificate when SkipWritingCSRFile is set
+	InjectionLog string
+	// Connections counts the connections accepted by the server
+	Connections int32
+	// EncryptToken makes the server encrypt the token for the client
+	// certificate when the client accepts a PKCS#7 response
+	EncryptToken bool
+	// EncryptedResponses counts the encrypted authenticate responses
+	EncryptedResponses int32
+	// CertLifetime is the validity period of the issued client certificates,
+	// 24 hours if unset
+	CertLifetime time.Duration
+	HandleLogin  func(
 		loginCsr *x509.CertificateRequest,
 		loginCsrErr error,
 	)
+	// HandleRequest is called with every request before it is handled
+	HandleRequest func(r *http.Request)
 }
 
 // testServer creates, for testing purposes, an http server on a random port that mocks conjur's
@@ -35,6 +54,7 @@ func NewTestAuthServer(clientCertPath, certLogPath, expectedTokenValue string, s
 		CertLogPath:        certLogPath,
 		ExpectedTokenValue: expectedTokenValue,
 		SkipWritingCSRFile: skipWritingCSRfile,
+		InjectionLog:       "error writing csr file\n",
 	}
 
 	authnCACertificate := &x509.Certificate{
@@ -43,9 +63,26 @@ func NewTestAuthServer(clientCertPath, certLogPath, expectedTokenValue string, s
 	authnCAPrivKey, _ := rsa.GenerateKey(rand.Reader, 4096)
 
 	ts.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ts.HandleRequest != nil {
+			ts.HandleRequest(r)
+		}
 
 		if strings.HasSuffix(r.URL.Path, "/authenticate") {
 			// Peer certificate from mutual auth
+			if ts.EncryptToken &&
+				strings.Contains(r.Header.Get("Accept"), "application/pkcs7-mime") &&
+				r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+				encrypted, err := pkcs7.Encrypt([]byte(ts.ExpectedTokenValue), r.TLS.PeerCertificates[:1])
+				if err != nil {
+					panic(err)
+				}
+				atomic.AddInt32(&ts.EncryptedResponses, 1)
+
+				w.Header().Set("Content-Type", "application/pkcs7-mime")
+				w.WriteHeader(201)
+				w.Write(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: encrypted}))
+				return
+			}
 
 			// Respond with a dummy token
 			w.WriteHeader(201)
@@ -67,18 +104,20 @@ func NewTestAuthServer(clientCertPath, certLogPath, expectedTokenValue string, s
 			w.WriteHeader(202)
 
 			if ts.SkipWritingCSRFile {
-				err := ioutil.WriteFile(ts.CertLogPath, []byte("error writing csr file\n"), os.ModePerm)
+				err := ioutil.WriteFile(ts.CertLogPath, []byte(ts.InjectionLog), os.ModePerm)
 				if err != nil {
 					panic(err)
 				}
 				return
 			}
 
+			lifetime := ts.CertLifetime
+			if lifetime == 0 {
+				lifetime = 24 * time.Hour
+			}
+
 			// Create client certificate template
 			clientCRTTemplate := x509.Certificate{
-				Signature:          loginCsr.Signature,
-				SignatureAlgorithm: loginCsr.SignatureAlgorithm,
-
 				PublicKeyAlgorithm: loginCsr.PublicKeyAlgorithm,
 				PublicKey:          loginCsr.PublicKey,
 
@@ -86,7 +125,7 @@ func NewTestAuthServer(clientCertPath, certLogPath, expectedTokenValue string, s
 				Issuer:       authnCACertificate.Subject,
 				Subject:      loginCsr.Subject,
 				NotBefore:    time.Now(),
-				NotAfter:     time.Now().Add(24 * time.Hour),
+				NotAfter:     time.Now().Add(lifetime),
 				KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
 				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 			}
@@ -111,6 +150,11 @@ func NewTestAuthServer(clientCertPath, certLogPath, expectedTokenValue string, s
 		}
 
 	}))
+	ts.Server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&ts.Connections, 1)
+		}
+	}
 	ts.Server.StartTLS()
 	ts.Server.TLS.ClientAuth = tls.RequestClientCert
 
@@ -0,0 +1,298 @@
+package common
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// caBundleExtensions are the extensions of the files read from a CA bundle
+// directory
+var caBundleExtensions = []string{".cer", ".crt", ".pem"}
+
+// CABundle is the pool of CA certificates trusted to verify Conjur. It is read
+// from PEM data, or from a PEM file or a directory of PEM files which is
+// reloaded when the files change, so that CAs can be rotated without a
+// restart.
+type CABundle struct {
+	certs       []byte
+	path        string
+	systemRoots bool
+
+	mutex    sync.Mutex
+	pins     []string
+	pinOnly  bool
+	pool     *x509.CertPool
+	subjects []string
+	state    string
+	// generation is incremented whenever the pool or the pins change, so
+	// that every client sharing the bundle notices the change
+	generation uint64
+}
+
+// caFile is a file of a CA bundle
+type caFile struct {
+	name    string
+	content []byte
+}
+
+// NewCABundle returns the CA bundle read from path, a PEM file or a directory
+// of .cer, .crt and .pem files, or from the PEM encoded certs if path is empty.
+// The system root CAs are also trusted if systemRoots is true.
+func NewCABundle(certs []byte, path string, systemRoots bool) (*CABundle, error) {
+	bundle := &CABundle{certs: certs, path: path, systemRoots: systemRoots}
+	if systemRoots {
+		log.Info(log.CAKC148)
+	}
+	if _, err := bundle.Reload(); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// Pool returns the current pool of CA certificates
+func (bundle *CABundle) Pool() *x509.CertPool {
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+	return bundle.pool
+}
+
+// Generation returns the number of times the pool or the pins of the bundle
+// changed. Clients built from an earlier generation must be rebuilt.
+func (bundle *CABundle) Generation() uint64 {
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+	return bundle.generation
+}
+
+// configureTLS sets the current CA pool and public key pinning of the bundle
+// up in a TLS config, and returns the generation they belong to
+func (bundle *CABundle) configureTLS(tlsConfig *tls.Config) uint64 {
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+	tlsConfig.RootCAs = bundle.pool
+	bundle.pinTLSConfig(tlsConfig)
+	return bundle.generation
+}
+
+// Subjects returns the subjects of the CA certificates of the bundle, not
+// including the system roots
+func (bundle *CABundle) Subjects() []string {
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+	return slices.Clone(bundle.subjects)
+}
+
+// Reload reads the bundle files again if they changed since they were last
+// read, and returns true if the pool was replaced. The previous pool is kept
+// if the files cannot be read or are invalid. Only the caller that triggers
+// the reload gets true, so clients sharing the bundle compare its Generation
+// instead.
+func (bundle *CABundle) Reload() (bool, error) {
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+
+	state := ""
+	if bundle.path != "" {
+		state = caBundleState(bundle.path)
+		if bundle.pool != nil && state == bundle.state {
+			return false, nil
+		}
+	} else if bundle.pool != nil {
+		return false, nil
+	}
+
+	pool, subjects, err := bundle.load()
+	if err != nil {
+		return false, err
+	}
+
+	source := bundle.path
+	if source == "" {
+		source = "CONJUR_SSL_CERTIFICATE"
+	}
+	log.Info(log.CAKC146, len(subjects), source, strings.Join(subjects, "; "))
+	bundle.pool, bundle.subjects, bundle.state = pool, subjects, state
+	bundle.generation++
+	return true, nil
+}
+
+func (bundle *CABundle) load() (*x509.CertPool, []string, error) {
+	pool := x509.NewCertPool()
+	if bundle.systemRoots {
+		systemPool, err := x509.SystemCertPool()
+		if err == nil {
+			pool = systemPool
+		}
+	}
+
+	var certs []*x509.Certificate
+	if bundle.path == "" {
+		var err error
+		certs, err = parseCACertificates(bundle.certs)
+		if err != nil {
+			return nil, nil, log.RecordedError(log.CAKC014)
+		}
+	} else {
+		files, err := readCAFiles(bundle.path, os.ReadFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, file := range files {
+			fileCerts, err := parseCAFile(file.name, file.content)
+			if err != nil {
+				return nil, nil, err
+			}
+			certs = append(certs, fileCerts...)
+		}
+	}
+
+	subjects := make([]string, len(certs))
+	for i, cert := range certs {
+		pool.AddCert(cert)
+		subjects[i] = cert.Subject.String()
+	}
+	return pool, subjects, nil
+}
+
+// ReadCABundle reads the CA bundle file or directory at path like NewCABundle,
+// and returns its certificates PEM encoded. Invalid files are reported by name.
+func ReadCABundle(path string, readFile ReadFileFunc) ([]byte, error) {
+	files, err := readCAFiles(path, readFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundle bytes.Buffer
+	for _, file := range files {
+		certs, err := parseCAFile(file.name, file.content)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			pem.Encode(&bundle, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		}
+	}
+	return bundle.Bytes(), nil
+}
+
+// readCAFiles reads the CA bundle file at path, or the .cer, .crt and .pem
+// files of the directory at path. Hidden files, such as the ..data directory
+// of Kubernetes volumes, are skipped.
+func readCAFiles(path string, readFile ReadFileFunc) ([]caFile, error) {
+	content, err := readFile(path)
+	if err == nil {
+		return []caFile{{name: path, content: content}}, nil
+	}
+	if !errors.Is(err, syscall.EISDIR) {
+		return nil, err
+	}
+
+	names, err := caBundleFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]caFile, 0, len(names))
+	for _, name := range names {
+		content, err := readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, caFile{name: name, content: content})
+	}
+	return files, nil
+}
+
+// caBundleFiles returns the paths of the CA files in a directory, sorted
+func caBundleFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || !slices.Contains(caBundleExtensions, filepath.Ext(name)) {
+			continue
+		}
+		names = append(names, filepath.Join(dir, name))
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf(log.CAKC145, dir, "no .cer, .crt or .pem file in the directory")
+	}
+	return names, nil
+}
+
+// parseCAFile parses the PEM encoded certificates of a CA bundle file, and
+// returns an error naming the file if it holds invalid PEM data
+func parseCAFile(name string, content []byte) ([]*x509.Certificate, error) {
+	certs, err := parseCACertificates(content)
+	if err != nil {
+		return nil, fmt.Errorf(log.CAKC145, name, err)
+	}
+	return certs, nil
+}
+
+func parseCACertificates(content []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := content
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return nil, errors.New("invalid PEM data")
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate")
+	}
+	return certs, nil
+}
+
+// caBundleState describes the size and modification time of the bundle
+// files, to detect changes
+func caBundleState(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano())
+	}
+
+	names, err := caBundleFiles(path)
+	if err != nil {
+		return ""
+	}
+	var state strings.Builder
+	for _, name := range names {
+		if info, err := os.Stat(name); err == nil {
+			fmt.Fprintf(&state, "%s/%d/%d;", name, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return state.String()
+}
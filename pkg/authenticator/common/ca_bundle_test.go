@@ -0,0 +1,201 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCAPEM returns a PEM encoded self-signed CA certificate
+func newTestCAPEM(t *testing.T, commonName string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw})
+}
+
+// writeCAFile writes a CA bundle file with a modification time in the future,
+// so that rewrites within the file system timestamp granularity are detected
+func writeCAFile(t *testing.T, path string, content []byte, age time.Duration) {
+	assert.NoError(t, os.WriteFile(path, content, 0644))
+	modTime := time.Now().Add(time.Hour - age)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestNewCABundle(t *testing.T) {
+	t.Run("PEM data", func(t *testing.T) {
+		certs := append(newTestCAPEM(t, "first"), newTestCAPEM(t, "second")...)
+		bundle, err := NewCABundle(certs, "", false)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"CN=first", "CN=second"}, bundle.Subjects())
+
+		reloaded, err := bundle.Reload()
+		assert.NoError(t, err)
+		assert.False(t, reloaded)
+	})
+
+	t.Run("invalid PEM data", func(t *testing.T) {
+		_, err := NewCABundle([]byte("samplecertificate"), "", false)
+		assert.EqualError(t, err, "CAKC014 Failed to append Conjur CA cert")
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCAFile(t, filepath.Join(dir, "b.pem"), newTestCAPEM(t, "b"), 0)
+		writeCAFile(t, filepath.Join(dir, "a.crt"), newTestCAPEM(t, "a"), 0)
+		writeCAFile(t, filepath.Join(dir, "README"), []byte("not a certificate"), 0)
+		assert.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0755))
+
+		bundle, err := NewCABundle([]byte("ignored"), dir, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"CN=a", "CN=b"}, bundle.Subjects())
+		}
+
+		pemData, err := ReadSSLCert(map[string]string{"CONJUR_CERT_FILE": dir}, os.ReadFile)
+		if assert.NoError(t, err) {
+			certs, err := parseCACertificates(pemData)
+			assert.NoError(t, err)
+			assert.Len(t, certs, 2)
+		}
+	})
+
+	t.Run("invalid file in directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCAFile(t, filepath.Join(dir, "a.crt"), newTestCAPEM(t, "a"), 0)
+		writeCAFile(t, filepath.Join(dir, "b.pem"), []byte("-----BEGIN CERTIFICATE-----\ninvalid\n"), 0)
+
+		_, err := NewCABundle(nil, dir, false)
+		assert.EqualError(t, err, "CAKC145 Invalid CA certificate file "+filepath.Join(dir, "b.pem")+". Reason: invalid PEM data")
+
+		_, err = ReadSSLCert(map[string]string{"CONJUR_CERT_FILE": dir}, os.ReadFile)
+		assert.EqualError(t, err, "CAKC145 Invalid CA certificate file "+filepath.Join(dir, "b.pem")+". Reason: invalid PEM data")
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := NewCABundle(nil, dir, false)
+		assert.EqualError(t, err, "CAKC145 Invalid CA certificate file "+dir+". Reason: no .cer, .crt or .pem file in the directory")
+	})
+
+	t.Run("system roots", func(t *testing.T) {
+		bundle, err := NewCABundle(newTestCAPEM(t, "conjur"), "", true)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"CN=conjur"}, bundle.Subjects())
+		}
+	})
+}
+
+func TestCABundleReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conjur.pem")
+	writeCAFile(t, path, newTestCAPEM(t, "old"), 2*time.Minute)
+
+	bundle, err := NewCABundle(nil, path, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	pool := bundle.Pool()
+
+	reloaded, err := bundle.Reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeCAFile(t, path, newTestCAPEM(t, "new"), time.Minute)
+	reloaded, err = bundle.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, []string{"CN=new"}, bundle.Subjects())
+	assert.NotSame(t, pool, bundle.Pool())
+	assert.Equal(t, uint64(2), bundle.Generation())
+
+	// An invalid bundle keeps the previous CAs
+	writeCAFile(t, path, []byte("invalid"), 0)
+	reloaded, err = bundle.Reload()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	assert.Equal(t, []string{"CN=new"}, bundle.Subjects())
+}
+
+func TestHTTPSClientReloadsCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	path := filepath.Join(t.TempDir(), "conjur.pem")
+	writeCAFile(t, path, newTestCAPEM(t, "other"), time.Minute)
+	bundle, err := NewCABundle(nil, path, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	client, err := NewHTTPSClientWithCABundle(bundle, TransportConfig{}, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = client.Get(server.URL)
+	assert.ErrorContains(t, err, "certificate signed by unknown authority")
+
+	writeCAFile(t, path, serverCA, 0)
+	resp, err := client.Get(server.URL)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestHTTPSClientsShareCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	path := filepath.Join(t.TempDir(), "conjur.pem")
+	writeCAFile(t, path, newTestCAPEM(t, "other"), time.Minute)
+	bundle, err := NewCABundle(nil, path, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	first, err := NewHTTPSClientWithCABundle(bundle, TransportConfig{}, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	second, err := NewHTTPSClientWithCABundle(bundle, TransportConfig{}, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The first client reloads the rotated CA, and the second one, which no
+	// longer sees the reload, uses it too
+	writeCAFile(t, path, serverCA, 0)
+	for _, client := range []*http.Client{first, second} {
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+}
@@ -1,26 +1,29 @@
 package common
 
 import (
+	"crypto"
 	"crypto/tls"
 	"crypto/x509"
 	"net/http"
-	"time"
+	"sync"
 
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 )
 
 // NewHTTPSClient Returns https client to communicate with Conjur
 func NewHTTPSClient(CACert []byte, certPEMBlock, keyPEMBlock []byte) (*http.Client, error) {
-	caCertPool := x509.NewCertPool()
-	ok := caCertPool.AppendCertsFromPEM(CACert)
-	if !ok {
-		return nil, log.RecordedError(log.CAKC014)
+	bundle, err := NewCABundle(CACert, "", false)
+	if err != nil {
+		return nil, err
 	}
+	return NewHTTPSClientWithCABundle(bundle, TransportConfig{}, certPEMBlock, keyPEMBlock)
+}
 
-	// Setup HTTPS client
-	tlsConfig := &tls.Config{
-		RootCAs: caCertPool,
-	}
+// NewHTTPSClientWithCABundle returns an https client trusting the CAs of the
+// bundle, which are reloaded when the bundle files change, and tuned with the
+// transport config
+func NewHTTPSClientWithCABundle(bundle *CABundle, transport TransportConfig, certPEMBlock, keyPEMBlock []byte) (*http.Client, error) {
+	tlsConfig := newTLSConfig()
 
 	if certPEMBlock != nil && keyPEMBlock != nil {
 		cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
@@ -34,7 +37,101 @@ func NewHTTPSClient(CACert []byte, certPEMBlock, keyPEMBlock []byte) (*http.Clie
 	}
 	// Doubt this is necessary because there's only one
 	//tlsConfig.BuildNameToCertificate()
-	transport := &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}
+	return newHTTPClient(bundle, transport, tlsConfig)
+}
+
+// NewHTTPSClientWithSigner returns an https client that presents the given
+// client certificate to Conjur. The TLS handshake is signed with signer, so the
+// private key does not need to be exportable.
+func NewHTTPSClientWithSigner(
+	bundle *CABundle,
+	transport TransportConfig,
+	clientCert *x509.Certificate,
+	signer crypto.Signer,
+) (*http.Client, error) {
+	tlsConfig := newTLSConfig()
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{clientCert.Raw},
+		PrivateKey:  signer,
+		Leaf:        clientCert,
+	}
+	tlsConfig.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return cert, nil
+	}
+
+	return newHTTPClient(bundle, transport, tlsConfig)
+}
+
+func newTLSConfig() *tls.Config {
+	return &tls.Config{
+		// Resume TLS sessions so that new connections skip the full handshake
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+	}
+}
+
+func newHTTPClient(bundle *CABundle, config TransportConfig, tlsConfig *tls.Config) (*http.Client, error) {
+	config = config.withDefaults()
+	config.applyTLS(tlsConfig)
+	base, err := config.newTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &caReloadingTransport{bundle: bundle, tlsConfig: tlsConfig, base: base}
+	transport.transport = transport.newTransport()
+
+	return &http.Client{Transport: transport, Timeout: config.Timeout}, nil
+}
+
+// caReloadingTransport sends requests with a transport trusting the current
+// CAs of the bundle. When the bundle is reloaded, it is replaced by a new
+// transport and the connections of the previous one are closed.
+type caReloadingTransport struct {
+	bundle    *CABundle
+	tlsConfig *tls.Config
+	base      *http.Transport
+
+	mutex     sync.Mutex
+	transport *http.Transport
+	// generation is the bundle generation the transport was built from
+	generation uint64
+}
+
+func (transport *caReloadingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	return transport.current().RoundTrip(request)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport,
+// for http.Client.CloseIdleConnections
+func (transport *caReloadingTransport) CloseIdleConnections() {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.transport.CloseIdleConnections()
+}
+
+func (transport *caReloadingTransport) current() *http.Transport {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if _, err := transport.bundle.Reload(); err != nil {
+		log.Warn(log.CAKC147, transport.bundle.path, err)
+	}
+	// The bundle may have been reloaded by another client sharing it
+	if transport.bundle.Generation() != transport.generation {
+		transport.transport.CloseIdleConnections()
+		transport.transport = transport.newTransport()
+	}
+	return transport.transport
+}
+
+// newTransport returns a transport trusting the current CAs of the bundle,
+// and records the bundle generation it was built from
+func (transport *caReloadingTransport) newTransport() *http.Transport {
+	tlsConfig := transport.tlsConfig.Clone()
+	transport.generation = transport.bundle.configureTLS(tlsConfig)
 
-	return &http.Client{Transport: transport, Timeout: time.Second * 10}, nil
+	next := transport.base.Clone()
+	next.TLSClientConfig = tlsConfig
+	return next
 }
@@ -1,56 +1,54 @@
 package common
 
 import (
+	"crypto/x509"
 	"fmt"
-	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
-	"strconv"
 	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 )
 
 // Config defines the configuration parameters common for both authentications
 type Config struct {
-	Account                   string
-	ClientCertPath            string
-	ClientCertRetryCountLimit int
-	ContainerMode             string
-	SSLCertificate            []byte
-	TokenFilePath             string
-	TokenRefreshTimeout       time.Duration
-	URL                       string
-	Username                  *Username
+	Account                   string        `setting:"CONJUR_ACCOUNT"`
+	CASystemRoots             bool          `setting:"CONJUR_CA_SYSTEM_ROOTS"`
+	CertFile                  string        `setting:"CONJUR_CERT_FILE"`
+	ClientCertPath            string        `setting:"CONJUR_CLIENT_CERT_PATH"`
+	ClientCertRetryCountLimit int           `setting:"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT"`
+	ContainerMode             string        `setting:"CONTAINER_MODE"`
+	SSLCertificate            []byte        `setting:"CONJUR_SSL_CERTIFICATE"`
+	TokenFilePath             string        `setting:"CONJUR_AUTHN_TOKEN_FILE"`
+	TokenRefreshTimeout       time.Duration `setting:"CONJUR_TOKEN_TIMEOUT"`
+	SSLPins                   []string      `setting:"CONJUR_SSL_PINS"`
+	SSLPinOnly                bool          `setting:"CONJUR_SSL_PIN_ONLY"`
+	URL                       string        `setting:"CONJUR_AUTHN_URL"`
+	Username                  *Username     `setting:"CONJUR_AUTHN_LOGIN"`
+	Transport                 TransportConfig
 }
 
 // LoadConfig is a constructor for common Config object
-func (config *Config) LoadConfig(settings map[string]string) {
-	for key, value := range settings {
-		switch key {
-		case "CONJUR_ACCOUNT":
-			config.Account = value
-		case "CONJUR_AUTHN_LOGIN":
-			if len(value) == 0 {
-				config.Username = nil
-			} else {
-				username, _ := NewUsername(value)
-				config.Username = username
-			}
-		case "CONJUR_AUTHN_URL":
-			config.URL = value
-		case "CONJUR_SSL_CERTIFICATE":
-			config.SSLCertificate = []byte(value)
-		case "CONTAINER_MODE":
-			config.ContainerMode = value
-		case "CONJUR_AUTHN_TOKEN_FILE":
-			config.TokenFilePath = value
-		case "CONJUR_CLIENT_CERT_PATH":
-			config.ClientCertPath = value
-		case "CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT":
-			limit, _ := strconv.Atoi(value)
-			config.ClientCertRetryCountLimit = limit
-		case "CONJUR_TOKEN_TIMEOUT":
-			timeout, _ := durationFromString(key, value)
-			config.TokenRefreshTimeout = timeout
+func (config *Config) LoadConfig(settings map[string]string) error {
+	return LoadSettings(config, settings)
+}
+
+// CABundle returns the CAs trusted to verify Conjur: the CONJUR_CERT_FILE
+// bundle if it is set, reloaded when it changes, or SSLCertificate otherwise.
+// The public keys in SSLPins are pinned, and with SSLPinOnly no CA
+// certificate is needed.
+func (config *Config) CABundle() (*CABundle, error) {
+	var bundle *CABundle
+	if config.SSLPinOnly && len(config.SSLPins) > 0 && len(config.SSLCertificate) == 0 && config.CertFile == "" {
+		bundle = &CABundle{pool: x509.NewCertPool()}
+	} else {
+		var err error
+		bundle, err = NewCABundle(config.SSLCertificate, config.CertFile, config.CASystemRoots)
+		if err != nil {
+			return nil, err
 		}
 	}
+
+	bundle.PinPublicKeys(config.SSLPins, config.SSLPinOnly)
+	return bundle, nil
 }
 
 func durationFromString(key, value string) (time.Duration, error) {
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LoadSettings sets the fields of the struct pointed to by target from the
+// settings named in their `setting` tags, parsed according to their type in
+// the settings registry. Untagged struct fields are loaded recursively, and
+// fields whose setting is absent are left unchanged.
+func LoadSettings(target interface{}, settings map[string]string) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot load settings into %T", target)
+	}
+	return loadStruct(value.Elem(), settings)
+}
+
+func loadStruct(value reflect.Value, settings map[string]string) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		fieldType := value.Type().Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		name, tagged := fieldType.Tag.Lookup("setting")
+		if !tagged {
+			if field.Kind() == reflect.Struct {
+				if err := loadStruct(field, settings); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		raw, ok := settings[name]
+		if !ok {
+			continue
+		}
+		setting, ok := LookupSetting(name)
+		if !ok {
+			return fmt.Errorf("setting %s of field %s is not registered", name, fieldType.Name)
+		}
+		parsed, err := setting.Parse(raw)
+		if err != nil {
+			return err
+		}
+
+		parsedValue := reflect.ValueOf(parsed)
+		if !parsedValue.Type().ConvertibleTo(field.Type()) {
+			return fmt.Errorf("setting %s cannot be loaded into field %s of type %s", name, fieldType.Name, field.Type())
+		}
+		field.Set(parsedValue.Convert(field.Type()))
+	}
+	return nil
+}
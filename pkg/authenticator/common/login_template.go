@@ -0,0 +1,70 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultPodInfoPath is the directory in which the pod metadata used by the
+// CONJUR_AUTHN_LOGIN placeholders is looked up, when it is not given in an
+// environment variable. Mount a downward API volume there.
+const DefaultPodInfoPath = "/etc/podinfo"
+
+// loginPlaceholders maps the placeholders allowed in CONJUR_AUTHN_LOGIN to the
+// environment variable holding their value. The value is otherwise read from
+// the file named after the placeholder in CONJUR_POD_INFO_PATH.
+var loginPlaceholders = map[string]string{
+	"namespace":       "MY_POD_NAMESPACE",
+	"pod_name":        "MY_POD_NAME",
+	"service_account": "MY_POD_SERVICE_ACCOUNT",
+	"deployment":      "MY_POD_DEPLOYMENT",
+}
+
+var loginPlaceholderPattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// ExpandLogin replaces the ${namespace}, ${pod_name}, ${service_account} and
+// ${deployment} placeholders in a CONJUR_AUTHN_LOGIN template with the pod
+// metadata returned by getEnv or found in the pod info directory. Placeholders
+// that cannot be resolved are left as is, and are reported when the setting is
+// validated.
+func ExpandLogin(login string, getEnv func(key string) string) string {
+	return ExpandLoginWithReader(login, getEnv, os.ReadFile)
+}
+
+// ExpandLoginWithReader expands a CONJUR_AUTHN_LOGIN template like
+// ExpandLogin, reading the pod info directory with readFile. The pod info
+// directory is not read if readFile is nil.
+func ExpandLoginWithReader(login string, getEnv func(key string) string, readFile ReadFileFunc) string {
+	podInfoPath := getEnv("CONJUR_POD_INFO_PATH")
+	if podInfoPath == "" {
+		podInfoPath = DefaultPodInfoPath
+	}
+
+	return loginPlaceholderPattern.ReplaceAllStringFunc(login, func(placeholder string) string {
+		name := placeholder[2 : len(placeholder)-1]
+		envVar, ok := loginPlaceholders[name]
+		if !ok {
+			return placeholder
+		}
+
+		if value := getEnv(envVar); value != "" {
+			return value
+		}
+		if readFile == nil {
+			return placeholder
+		}
+		content, err := readFile(filepath.Join(podInfoPath, name))
+		if value := strings.TrimSpace(string(content)); err == nil && value != "" {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// unresolvedLoginPlaceholder returns the first placeholder left in an expanded
+// CONJUR_AUTHN_LOGIN, or an empty string if there is none
+func unresolvedLoginPlaceholder(login string) string {
+	return loginPlaceholderPattern.FindString(login)
+}
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandLogin(t *testing.T) {
+	podInfoPath := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(podInfoPath, "deployment"), []byte("app-deployment\n"), 0644))
+
+	env := map[string]string{
+		"CONJUR_POD_INFO_PATH":   podInfoPath,
+		"MY_POD_NAMESPACE":       "app-namespace",
+		"MY_POD_SERVICE_ACCOUNT": "app-sa",
+	}
+	getEnv := func(key string) string {
+		return env[key]
+	}
+
+	testCases := []struct {
+		description string
+		login       string
+		expected    string
+	}{
+		{
+			description: "no placeholders",
+			login:       "host/conjur/authn-k8s/my-authenticator-id/apps/app-sa",
+			expected:    "host/conjur/authn-k8s/my-authenticator-id/apps/app-sa",
+		},
+		{
+			description: "placeholders from environment variables",
+			login:       "host/apps/${namespace}/service_account/${service_account}",
+			expected:    "host/apps/app-namespace/service_account/app-sa",
+		},
+		{
+			description: "placeholder from the pod info directory",
+			login:       "host/apps/${namespace}/deployment/${deployment}",
+			expected:    "host/apps/app-namespace/deployment/app-deployment",
+		},
+		{
+			description: "unresolved placeholder is left as is",
+			login:       "host/apps/${namespace}/pod/${pod_name}",
+			expected:    "host/apps/app-namespace/pod/${pod_name}",
+		},
+		{
+			description: "unknown placeholder is left as is",
+			login:       "host/apps/${namespace}/${node}",
+			expected:    "host/apps/app-namespace/${node}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			expanded := ExpandLogin(tc.login, getEnv)
+			assert.Equal(t, tc.expected, expanded)
+		})
+	}
+}
+
+func TestValidUsernamePlaceholder(t *testing.T) {
+	err := validUsername("CONJUR_AUTHN_LOGIN", "host/apps/${namespace}/${pod_name}")
+	assert.EqualError(
+		t,
+		err,
+		"CAKC133 Placeholder ${namespace} in CONJUR_AUTHN_LOGIN could not be resolved from the pod metadata",
+	)
+}
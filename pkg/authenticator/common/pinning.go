@@ -0,0 +1,227 @@
+package common
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// spkiPinPrefix is the prefix of SHA-256 public key pins, as used by curl's
+// --pinnedpubkey
+const spkiPinPrefix = "sha256//"
+
+// SPKIFingerprint returns the sha256//<base64> SHA-256 fingerprint of the DER
+// encoded SubjectPublicKeyInfo of the certificate
+func SPKIFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return spkiPinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func validSPKIPin(pin string) bool {
+	digest, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, spkiPinPrefix))
+	return strings.HasPrefix(pin, spkiPinPrefix) && err == nil && len(digest) == sha256.Size
+}
+
+// PinPublicKeys requires the certificate chain of the Conjur server to include
+// a public key with one of the given fingerprints. The chain must still be
+// verified with the CA certificates of the bundle, unless pinOnly is true, in
+// which case the server certificate itself must have a pinned public key.
+func (bundle *CABundle) PinPublicKeys(pins []string, pinOnly bool) {
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+	bundle.pins = pins
+	bundle.pinOnly = pinOnly && len(pins) > 0
+	bundle.generation++
+}
+
+// pinTLSConfig sets the public key pinning of the bundle up in a TLS config.
+// The bundle mutex must be held.
+func (bundle *CABundle) pinTLSConfig(tlsConfig *tls.Config) {
+	if len(bundle.pins) == 0 {
+		return
+	}
+
+	pins, pinOnly := bundle.pins, bundle.pinOnly
+	// Without CA verification, only the server certificate is known to be
+	// presented by its private key holder
+	tlsConfig.InsecureSkipVerify = pinOnly
+	tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if pinOnly {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf(log.CAKC151, "[]")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			return verifyPinnedChain([]*x509.Certificate{cert}, pins)
+		}
+
+		var errs []error
+		for _, chain := range verifiedChains {
+			err := verifyPinnedChain(chain, pins)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// verifyPinnedChain returns an error unless one of the certificates has a
+// pinned public key
+func verifyPinnedChain(chain []*x509.Certificate, pins []string) error {
+	fingerprints := make([]string, len(chain))
+	for i, cert := range chain {
+		fingerprints[i] = SPKIFingerprint(cert)
+		if slices.Contains(pins, fingerprints[i]) {
+			return nil
+		}
+	}
+	return fmt.Errorf(log.CAKC151, fingerprints)
+}
+
+// BootstrapCA fetches the certificate chain of the Conjur server at conjurURL,
+// checks that it is valid for the TLS server name of the transport, or the
+// host of conjurURL if it is not set, and includes a pinned public key, and
+// writes the top-most CA certificate of the chain to certFile. The server is
+// trusted on first use through its pin, so that only the pin needs to be
+// distributed.
+func BootstrapCA(conjurURL string, transport TransportConfig, pins []string, certFile string) error {
+	chain, err := fetchPinnedChain(conjurURL, transport, pins)
+	if err != nil {
+		return log.RecordedError(log.CAKC150, conjurURL, err)
+	}
+
+	ca := chain[len(chain)-1]
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	if err := writeFileAtomically(certFile, content, 0644); err != nil {
+		return log.RecordedError(log.CAKC150, conjurURL, err)
+	}
+
+	log.Info(log.CAKC149, ca.Subject, certFile)
+	return nil
+}
+
+// writeFileAtomically writes the file through a temporary file renamed over
+// it, so that containers sharing the file never read it partially written
+func writeFileAtomically(path string, content []byte, perm os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), perm)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// fetchPinnedChain returns the verified certificate chain of the server, from
+// its certificate to the top-most certificate it presents. The server is
+// reached through the proxy and with the timeouts of the transport, like the
+// other Conjur requests.
+func fetchPinnedChain(conjurURL string, transport TransportConfig, pins []string) ([]*x509.Certificate, error) {
+	parsed, err := url.Parse(conjurURL)
+	if err != nil {
+		return nil, err
+	}
+	serverName := transport.TLSServerName
+	if serverName == "" {
+		serverName = parsed.Hostname()
+	}
+
+	transport = transport.withDefaults()
+	base, err := transport.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	defer base.CloseIdleConnections()
+
+	var chain []*x509.Certificate
+	var verifyErr error
+	base.TLSClientConfig = &tls.Config{
+		MinVersion: transport.TLSMinVersion,
+		ServerName: serverName,
+		// The chain is verified against its own top-most certificate during
+		// the handshake, so that nothing is sent to an unpinned server
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			chain, verifyErr = verifyPresentedChain(state.PeerCertificates, serverName, pins)
+			return verifyErr
+		},
+	}
+
+	request, err := http.NewRequest(http.MethodHead, conjurURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: base, Timeout: transport.Timeout}
+	response, err := client.Do(request)
+	if err == nil {
+		response.Body.Close()
+	}
+	switch {
+	case verifyErr != nil:
+		return nil, verifyErr
+	case chain == nil && err == nil:
+		return nil, errors.New("no server certificate")
+	case chain == nil:
+		return nil, err
+	}
+	return chain, nil
+}
+
+// verifyPresentedChain returns the chain presented by the server, verified
+// against its top-most certificate, if it includes a pinned public key
+func verifyPresentedChain(presented []*x509.Certificate, serverName string, pins []string) ([]*x509.Certificate, error) {
+	if len(presented) == 0 {
+		return nil, errors.New("no server certificate")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(presented[len(presented)-1])
+	intermediates := x509.NewCertPool()
+	for _, cert := range presented[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := presented[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, chain := range chains {
+		if err := verifyPinnedChain(chain, pins); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return chain, nil
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSPKIPinSetting(t *testing.T) {
+	sum := sha256.Sum256([]byte("public key"))
+	pin := "sha256//" + base64.StdEncoding.EncodeToString(sum[:])
+
+	assert.NoError(t, ValidateSetting("CONJUR_SSL_PINS", pin))
+	assert.NoError(t, ValidateSetting("CONJUR_SSL_PINS", pin+", "+pin))
+	for _, invalid := range []string{
+		base64.StdEncoding.EncodeToString(sum[:]),
+		"sha256//" + base64.StdEncoding.EncodeToString(sum[:16]),
+		"sha256//not base64",
+		pin + ",md5//abc",
+	} {
+		assert.EqualError(
+			t,
+			ValidateSetting("CONJUR_SSL_PINS", invalid),
+			"CAKC060 Setting CONJUR_SSL_PINS given invalid value "+invalid,
+		)
+	}
+}
+
+func TestPinnedHTTPSClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	serverPin := SPKIFingerprint(server.Certificate())
+	otherPin := "sha256//" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	TestCases := []struct {
+		description string
+		config      Config
+		err         string
+	}{
+		{
+			description: "CA and pin",
+			config:      Config{SSLCertificate: serverCA, SSLPins: []string{otherPin, serverPin}},
+		},
+		{
+			description: "CA and wrong pin",
+			config:      Config{SSLCertificate: serverCA, SSLPins: []string{otherPin}},
+			err:         "CAKC151 The public keys of the Conjur server certificate chain [" + serverPin + "] do not match CONJUR_SSL_PINS",
+		},
+		{
+			description: "pin only",
+			config:      Config{SSLPins: []string{serverPin}, SSLPinOnly: true},
+		},
+		{
+			description: "pin only with a wrong pin",
+			config:      Config{SSLPins: []string{otherPin}, SSLPinOnly: true},
+			err:         "CAKC151 The public keys of the Conjur server certificate chain [" + serverPin + "] do not match CONJUR_SSL_PINS",
+		},
+		{
+			description: "pin only with another CA",
+			config:      Config{SSLCertificate: newTestCAPEM(t, "other"), SSLPins: []string{serverPin}, SSLPinOnly: true},
+		},
+		{
+			description: "pin without CA verification",
+			config:      Config{SSLCertificate: newTestCAPEM(t, "other"), SSLPins: []string{serverPin}},
+			err:         "certificate signed by unknown authority",
+		},
+	}
+
+	for _, tc := range TestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			bundle, err := tc.config.CABundle()
+			if !assert.NoError(t, err) {
+				return
+			}
+			client, err := NewHTTPSClientWithCABundle(bundle, TransportConfig{}, nil, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp, err := client.Get(server.URL)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestBootstrapCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverPin := SPKIFingerprint(server.Certificate())
+
+	t.Run("matching pin", func(t *testing.T) {
+		certFile := filepath.Join(t.TempDir(), "conjur.pem")
+		err := BootstrapCA(server.URL+"/authn-k8s/my-authenticator-id", TransportConfig{}, []string{serverPin}, certFile)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		content, err := os.ReadFile(certFile)
+		assert.NoError(t, err)
+		assert.Equal(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), content)
+
+		bundle, err := NewCABundle(nil, certFile, false)
+		if assert.NoError(t, err) {
+			client, _ := NewHTTPSClientWithCABundle(bundle, TransportConfig{}, nil, nil)
+			resp, err := client.Get(server.URL)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}
+	})
+
+	t.Run("wrong pin", func(t *testing.T) {
+		certFile := filepath.Join(t.TempDir(), "conjur.pem")
+		otherPin := "sha256//" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+		err := BootstrapCA(server.URL, TransportConfig{}, []string{otherPin}, certFile)
+		assert.EqualError(
+			t,
+			err,
+			"CAKC150 Failed to bootstrap the Conjur CA certificate from "+server.URL+". Reason: "+
+				"CAKC151 The public keys of the Conjur server certificate chain ["+serverPin+"] do not match CONJUR_SSL_PINS",
+		)
+		assert.NoFileExists(t, certFile)
+	})
+
+	t.Run("through the proxy", func(t *testing.T) {
+		var connects atomic.Int32
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			connects.Add(1)
+			target, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			conn, buffered, _ := w.(http.Hijacker).Hijack()
+			go func() {
+				io.Copy(target, buffered)
+				target.Close()
+			}()
+			io.Copy(conn, target)
+			conn.Close()
+		}))
+		defer proxy.Close()
+
+		dir := t.TempDir()
+		certFile := filepath.Join(dir, "conjur.pem")
+		err := BootstrapCA(server.URL, TransportConfig{ProxyURL: proxy.URL}, []string{serverPin}, certFile)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), connects.Load())
+
+		// The temporary file is renamed to the certificate file
+		entries, _ := os.ReadDir(dir)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "conjur.pem", entries[0].Name())
+		}
+	})
+
+	t.Run("connect timeout", func(t *testing.T) {
+		certFile := filepath.Join(t.TempDir(), "conjur.pem")
+		transport := TransportConfig{ConnectTimeout: time.Nanosecond}
+		err := BootstrapCA(server.URL, transport, []string{serverPin}, certFile)
+		assert.ErrorContains(t, err, "timeout")
+		assert.NoFileExists(t, certFile)
+	})
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/version"
+)
+
+// RequestIDHeader is the header carrying the ID of an authentication attempt.
+// Conjur logs it as the request ID of the requests of that attempt.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDAttribute is the tracing span attribute carrying the request ID
+const RequestIDAttribute = "conjur.request_id"
+
+// UserAgent returns the User-Agent header value identifying the client
+// version and the authenticator type to Conjur
+func UserAgent(authnType string) string {
+	return fmt.Sprintf("conjur-authn-k8s-client/%s (%s)", version.FullVersionName, authnType)
+}
+
+// NewRequestID returns a random ID for an authentication attempt
+func NewRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// SetRequestID sets the ID of the authentication attempt sending a request
+func SetRequestID(req *http.Request, requestID string) {
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserAgent(t *testing.T) {
+	// The version is known without importing the authenticator package
+	assert.Equal(t, "conjur-authn-k8s-client/vunset-dev (authn-jwt)", UserAgent(AuthnTypeJWT))
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := NewRequestID()
+	assert.Regexp(t, "^[0-9a-f]{32}$", id)
+	assert.NotEqual(t, id, NewRequestID())
+}
+
+func TestSetRequestID(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://conjur/authn-jwt/account/authenticate", nil)
+
+	SetRequestID(req, "")
+	assert.Empty(t, req.Header.Values(RequestIDHeader))
+
+	SetRequestID(req, "abc")
+	assert.Equal(t, "abc", req.Header.Get(RequestIDHeader))
+}
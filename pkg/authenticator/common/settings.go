@@ -0,0 +1,738 @@
+package common
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// Authenticator types the settings apply to
+const (
+	AuthnTypeK8s = "authn-k8s"
+	AuthnTypeJWT = "authn-jwt"
+)
+
+// Key algorithms accepted in CONJUR_CLIENT_KEY_ALGORITHM
+const (
+	KeyAlgorithmRSA2048   = "rsa-2048"
+	KeyAlgorithmRSA3072   = "rsa-3072"
+	KeyAlgorithmRSA4096   = "rsa-4096"
+	KeyAlgorithmECDSAP256 = "ecdsa-p256"
+	KeyAlgorithmECDSAP384 = "ecdsa-p384"
+)
+
+// Key providers accepted in CONJUR_CLIENT_KEY_PROVIDER
+const (
+	// KeyProviderMemory generates the private key in process memory
+	KeyProviderMemory = "memory"
+	// KeyProviderPKCS11 generates a non-extractable private key in a PKCS#11 token
+	KeyProviderPKCS11 = "pkcs11"
+)
+
+// Default values of the settings, referenced by the settings registry
+const (
+	DefaultTokenFilePath             = "/run/conjur/access-token"
+	DefaultClientCertPath            = "/etc/conjur/ssl/client.pem"
+	DefaultClientCertRetryCountLimit = "10"
+	// DefaultClientCertRenewalFraction disables background renewal
+	DefaultClientCertRenewalFraction = "0"
+	DefaultKeyAlgorithm              = KeyAlgorithmRSA4096
+	DefaultKeyProvider               = KeyProviderMemory
+	DefaultSPIFFETrustDomain         = "cluster.local"
+	DefaultTLSMinVersion             = "1.2"
+	// DefaultTokenRefreshTimeout is the time the client waits to reauthenticate on error
+	DefaultTokenRefreshTimeout    = "6m0s"
+	DefaultJWTClockSkew           = "1m0s"
+	DefaultJWTTokenPath           = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	DefaultJWTTokenSource         = "file"
+	DefaultKubernetesAPIURL       = "https://kubernetes.default.svc"
+	DefaultTokenRequestExpiration = "10m0s"
+)
+
+// SettingType defines how the value of a setting is validated and loaded
+type SettingType string
+
+// Setting types
+const (
+	SettingString SettingType = "string"
+	// SettingPath is the path of a file or directory read by the client
+	SettingPath SettingType = "path"
+	// SettingWritablePath is the path of a file the client must be able to write
+	SettingWritablePath SettingType = "writable-path"
+	SettingInt          SettingType = "int"
+	SettingBool         SettingType = "bool"
+	SettingDuration     SettingType = "duration"
+	// SettingFraction is a number in [0, 1)
+	SettingFraction    SettingType = "fraction"
+	SettingURL         SettingType = "url"
+	SettingIP          SettingType = "ip"
+	SettingTrustDomain SettingType = "trust-domain"
+	SettingUsername    SettingType = "username"
+	// SettingList is a comma-separated list
+	SettingList SettingType = "list"
+	// SettingSPKIPins is a comma-separated list of sha256//<base64> SHA-256
+	// fingerprints of public keys
+	SettingSPKIPins SettingType = "spki-pins"
+	// SettingTLSVersion is a TLS version, 1.2 or 1.3
+	SettingTLSVersion SettingType = "tls-version"
+	// SettingHeaders is a comma-separated list of Name=value HTTP headers,
+	// with percent-encoded values
+	SettingHeaders SettingType = "headers"
+)
+
+// Setting describes a configuration setting of the authenticator client
+type Setting struct {
+	Name        string
+	Type        SettingType
+	Default     string
+	Description string
+	// AuthnTypes are the authenticators using the setting
+	AuthnTypes []string
+	// RequiredBy are the authenticators that fail without the setting
+	RequiredBy []string
+	// Sensitive values are not printed, only their fingerprint
+	Sensitive bool
+	// Values, if not empty, are the only values accepted
+	Values []string
+}
+
+var both = []string{AuthnTypeK8s, AuthnTypeJWT}
+var k8sOnly = []string{AuthnTypeK8s}
+var jwtOnly = []string{AuthnTypeJWT}
+
+// settingsRegistry holds every setting of the authenticator client, sorted by
+// name. Validation, loading, defaults and the JSON schema are derived from it.
+var settingsRegistry = []Setting{
+	{
+		Name:        "CONJUR_ACCOUNT",
+		Type:        SettingString,
+		Description: "Conjur account name",
+		AuthnTypes:  both,
+		RequiredBy:  both,
+	},
+	{
+		Name:        "CONJUR_ANNOTATIONS_FILE",
+		Type:        SettingPath,
+		Description: "Downward API file with the pod annotations configuring the client",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_AUTHN_LOGIN",
+		Type:        SettingUsername,
+		Description: "Conjur host to authenticate as, may use pod metadata placeholders",
+		AuthnTypes:  both,
+		RequiredBy:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_AUTHN_TOKEN_FILE",
+		Type:        SettingPath,
+		Default:     DefaultTokenFilePath,
+		Description: "File in which the Conjur access token is written",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_AUTHN_URL",
+		Type:        SettingString,
+		Description: "URL of the Conjur authenticator service endpoint",
+		AuthnTypes:  both,
+		RequiredBy:  both,
+	},
+	{
+		Name:        "CONJUR_CA_SYSTEM_ROOTS",
+		Type:        SettingBool,
+		Description: "Trust the system root CA certificates in addition to the Conjur CA certificates",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_CERT_FILE",
+		Type:        SettingPath,
+		Description: "PEM file, or directory of .cer, .crt and .pem files, with the Conjur CA certificates, used when CONJUR_SSL_CERTIFICATE is not set and reloaded when it changes",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_CLIENT_CERT_CACHE_KEY_PATH",
+		Type:        SettingPath,
+		Description: "File with the key encrypting the client certificate cache",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_CLIENT_CERT_CACHE_PATH",
+		Type:        SettingPath,
+		Description: "File in which the private key and client certificate are cached",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_CLIENT_CERT_PATH",
+		Type:        SettingPath,
+		Default:     DefaultClientCertPath,
+		Description: "File in which Conjur injects the client certificate",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_CLIENT_CERT_RENEWAL_FRACTION",
+		Type:        SettingFraction,
+		Default:     DefaultClientCertRenewalFraction,
+		Description: "Fraction of the client certificate lifetime after which it is renewed, 0 disables renewal",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT",
+		Type:        SettingInt,
+		Default:     DefaultClientCertRetryCountLimit,
+		Description: "Number of checks, 50ms apart, for the injected client certificate before giving up",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_CLIENT_KEY_ALGORITHM",
+		Type:        SettingString,
+		Default:     DefaultKeyAlgorithm,
+		Description: "Algorithm of the key pair generated for the client certificate",
+		AuthnTypes:  k8sOnly,
+		Values: []string{
+			KeyAlgorithmRSA2048,
+			KeyAlgorithmRSA3072,
+			KeyAlgorithmRSA4096,
+			KeyAlgorithmECDSAP256,
+			KeyAlgorithmECDSAP384,
+		},
+	},
+	{
+		Name:        "CONJUR_CLIENT_KEY_PROVIDER",
+		Type:        SettingString,
+		Default:     DefaultKeyProvider,
+		Description: "Where the private key is generated, memory or pkcs11",
+		AuthnTypes:  k8sOnly,
+		Values:      []string{KeyProviderMemory, KeyProviderPKCS11},
+	},
+	{
+		Name:        "CONJUR_CONFIG_FILE",
+		Type:        SettingPath,
+		Description: "YAML or JSON file with the client settings",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_CONNECT_TIMEOUT",
+		Type:        SettingDuration,
+		Default:     DefaultConnectTimeout.String(),
+		Description: "Timeout for connecting to Conjur",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_HTTP_HEADERS",
+		Type:        SettingHeaders,
+		Description: "Comma-separated Name=value HTTP headers added to the Conjur requests, with percent-encoded values",
+		AuthnTypes:  both,
+		Sensitive:   true,
+	},
+	{
+		Name:        "CONJUR_HTTP_TIMEOUT",
+		Type:        SettingDuration,
+		Default:     DefaultHTTPTimeout.String(),
+		Description: "Timeout for a Conjur request, including the connection and reading the response",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_PKCS11_MODULE",
+		Type:        SettingPath,
+		Description: "PKCS#11 module used by the pkcs11 key provider",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_PKCS11_PIN_PATH",
+		Type:        SettingPath,
+		Description: "File with the user PIN of the PKCS#11 token",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_PKCS11_TOKEN_LABEL",
+		Type:        SettingString,
+		Description: "Label of the PKCS#11 token in which the private key is generated",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_POD_INFO_PATH",
+		Type:        SettingPath,
+		Default:     DefaultPodInfoPath,
+		Description: "Downward API directory with the pod metadata used in CONJUR_AUTHN_LOGIN placeholders",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_PROXY_CA_FILE",
+		Type:        SettingPath,
+		Description: "PEM file, or directory of PEM files, with the CA certificates of an https CONJUR_PROXY_URL",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_PROXY_URL",
+		Type:        SettingURL,
+		Description: "URL of the proxy for the Conjur requests, instead of the HTTPS_PROXY and NO_PROXY environment variables",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_SPIFFE_TRUST_DOMAIN",
+		Type:        SettingTrustDomain,
+		Default:     DefaultSPIFFETrustDomain,
+		Description: "Trust domain of the SPIFFE ID in the login CSR",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "CONJUR_SSL_BOOTSTRAP",
+		Type:        SettingBool,
+		Description: "Write the Conjur CA certificate matching CONJUR_SSL_PINS to CONJUR_CERT_FILE if the file does not exist",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_SSL_CERTIFICATE",
+		Type:        SettingString,
+		Description: "Conjur SSL certificate",
+		AuthnTypes:  both,
+		Sensitive:   true,
+	},
+	{
+		Name:        "CONJUR_SSL_PINS",
+		Type:        SettingSPKIPins,
+		Description: "Comma-separated sha256//<base64> SHA-256 fingerprints of the public keys of which the Conjur server certificate chain must include one",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_SSL_PIN_ONLY",
+		Type:        SettingBool,
+		Description: "Verify the Conjur server with CONJUR_SSL_PINS only, instead of in addition to its CA certificates",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_STRICT_SETTINGS",
+		Type:        SettingBool,
+		Description: "Fail on unknown settings instead of logging warnings",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_TLS_HANDSHAKE_TIMEOUT",
+		Type:        SettingDuration,
+		Default:     DefaultTLSHandshakeTimeout.String(),
+		Description: "Timeout for the TLS handshake with Conjur",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_TLS_MIN_VERSION",
+		Type:        SettingTLSVersion,
+		Default:     DefaultTLSMinVersion,
+		Description: "Minimum TLS version for Conjur connections, 1.2 or 1.3",
+		AuthnTypes:  both,
+		Values:      []string{"1.2", "1.3"},
+	},
+	{
+		Name:        "CONJUR_TLS_SERVER_NAME",
+		Type:        SettingString,
+		Description: "Name expected in the Conjur server certificate and sent as SNI, instead of the host of CONJUR_AUTHN_URL",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONJUR_TOKEN_TIMEOUT",
+		Type:        SettingDuration,
+		Default:     DefaultTokenRefreshTimeout,
+		Description: "Time between authentications",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "CONTAINER_MODE",
+		Type:        SettingString,
+		Description: "init to exit after authenticating, sidecar otherwise",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "DEBUG",
+		Type:        SettingString,
+		Description: "Deprecated, true for debug logs. Use LOG_LEVEL instead",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "JWT_CLOCK_SKEW",
+		Type:        SettingDuration,
+		Default:     DefaultJWTClockSkew,
+		Description: "Allowed clock drift when checking the expiry of the JWT",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_EXPECTED_AUDIENCE",
+		Type:        SettingString,
+		Description: "Audience the JWT must have",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_EXPECTED_ISSUER",
+		Type:        SettingString,
+		Description: "Issuer the JWT must have",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_IDENTITY_CLAIM",
+		Type:        SettingString,
+		Description: "Claim the JWT must have when CONJUR_AUTHN_LOGIN is not set",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_OIDC_AUDIENCE",
+		Type:        SettingString,
+		Description: "Audience requested from the OIDC token endpoint",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_OIDC_CLIENT_ID",
+		Type:        SettingString,
+		Description: "OAuth2 client ID of the oidc JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_OIDC_CLIENT_SECRET_PATH",
+		Type:        SettingPath,
+		Description: "File with the OAuth2 client secret of the oidc JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_OIDC_KEY_ID",
+		Type:        SettingString,
+		Description: "Key ID of the private_key_jwt client assertion",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_OIDC_PRIVATE_KEY_PATH",
+		Type:        SettingPath,
+		Description: "File with the private key signing the private_key_jwt client assertion",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_OIDC_SCOPE",
+		Type:        SettingString,
+		Description: "Scope requested from the OIDC token endpoint",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_OIDC_TOKEN_URL",
+		Type:        SettingURL,
+		Description: "OAuth2/OIDC token endpoint of the oidc JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_SPIFFE_AUDIENCE",
+		Type:        SettingString,
+		Description: "Audience of the JWT-SVID fetched by the spiffe JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_SPIFFE_ID",
+		Type:        SettingString,
+		Description: "SPIFFE ID of the JWT-SVID fetched by the spiffe JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_TOKEN_COMMAND",
+		Type:        SettingString,
+		Description: "Command printing the JWT for the exec JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_TOKEN_ENV_VAR",
+		Type:        SettingString,
+		Description: "Environment variable holding the JWT for the env JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_TOKEN_PATH",
+		Type:        SettingWritablePath,
+		Default:     DefaultJWTTokenPath,
+		Description: "File with the JWT for the file JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_TOKEN_REQUEST_API_URL",
+		Type:        SettingURL,
+		Default:     DefaultKubernetesAPIURL,
+		Description: "Kubernetes API used by the token-request JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_TOKEN_REQUEST_AUDIENCE",
+		Type:        SettingString,
+		Description: "Audience of the service account token minted by the token-request JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_TOKEN_REQUEST_EXPIRATION",
+		Type:        SettingDuration,
+		Default:     DefaultTokenRequestExpiration,
+		Description: "Lifetime of the service account token minted by the token-request JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "JWT_TOKEN_SOURCE",
+		Type:        SettingList,
+		Default:     DefaultJWTTokenSource,
+		Description: "Comma-separated JWT sources tried in order: file, env, exec, oidc, token-request or spiffe",
+		AuthnTypes:  jwtOnly,
+	},
+	{
+		Name:        "LOG_LEVEL",
+		Type:        SettingString,
+		Description: "debug, info, warn or error",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "MY_POD_DEPLOYMENT",
+		Type:        SettingString,
+		Description: "Deployment of the pod, used in CONJUR_AUTHN_LOGIN placeholders",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "MY_POD_IP",
+		Type:        SettingIP,
+		Description: "Pod IP, added to the login CSR as a SAN",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "MY_POD_NAME",
+		Type:        SettingString,
+		Description: "Pod name",
+		AuthnTypes:  k8sOnly,
+		RequiredBy:  k8sOnly,
+	},
+	{
+		Name:        "MY_POD_NAMESPACE",
+		Type:        SettingString,
+		Description: "Pod namespace",
+		AuthnTypes:  both,
+		RequiredBy:  k8sOnly,
+	},
+	{
+		Name:        "MY_POD_SERVICE_ACCOUNT",
+		Type:        SettingString,
+		Description: "Pod service account",
+		AuthnTypes:  both,
+	},
+	{
+		Name:        "MY_POD_UID",
+		Type:        SettingString,
+		Description: "Pod UID, added to the login CSR as a SAN",
+		AuthnTypes:  k8sOnly,
+	},
+	{
+		Name:        "SPIFFE_ENDPOINT_SOCKET",
+		Type:        SettingString,
+		Description: "Address of the SPIFFE Workload API used by the spiffe JWT source",
+		AuthnTypes:  jwtOnly,
+	},
+}
+
+// Settings returns every setting of the authenticator client, sorted by name
+func Settings() []Setting {
+	return append([]Setting(nil), settingsRegistry...)
+}
+
+// LookupSetting returns the setting with the given name
+func LookupSetting(name string) (Setting, bool) {
+	for _, setting := range settingsRegistry {
+		if setting.Name == name {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+// SettingNames returns the names of the settings used by an authenticator
+func SettingNames(authnType string) []string {
+	names := []string{}
+	for _, setting := range settingsRegistry {
+		if setting.UsedBy(authnType) {
+			names = append(names, setting.Name)
+		}
+	}
+	return names
+}
+
+// RequiredSettingNames returns the names of the settings an authenticator
+// requires
+func RequiredSettingNames(authnType string) []string {
+	names := []string{}
+	for _, setting := range settingsRegistry {
+		if setting.RequiredFor(authnType) {
+			names = append(names, setting.Name)
+		}
+	}
+	return names
+}
+
+// DefaultSettingValues returns the default values of the settings used by an
+// authenticator
+func DefaultSettingValues(authnType string) map[string]string {
+	defaults := map[string]string{}
+	for _, setting := range settingsRegistry {
+		if setting.UsedBy(authnType) && setting.Default != "" {
+			defaults[setting.Name] = setting.Default
+		}
+	}
+	return defaults
+}
+
+// UsedBy returns true if the authenticator uses the setting
+func (setting Setting) UsedBy(authnType string) bool {
+	return slices.Contains(setting.AuthnTypes, authnType)
+}
+
+// RequiredFor returns true if the authenticator requires the setting
+func (setting Setting) RequiredFor(authnType string) bool {
+	return slices.Contains(setting.RequiredBy, authnType)
+}
+
+// Validate checks that a value is valid for the setting. Empty values are
+// valid, required settings are checked separately. Writable paths are checked
+// by creating a file at the path if it does not exist.
+func (setting Setting) Validate(value string) error {
+	if setting.Type == SettingWritablePath && len(value) > 0 {
+		return validatePath(value)
+	}
+	return setting.Check(value)
+}
+
+// Check checks that a value is valid for the setting like Validate, without
+// accessing the filesystem: any writable path is accepted
+func (setting Setting) Check(value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+
+	switch setting.Type {
+	case SettingWritablePath:
+		return nil
+	case SettingURL:
+		return validURL(setting.Name, value)
+	case SettingIP:
+		return validIP(setting.Name, value)
+	case SettingTrustDomain:
+		return validTrustDomain(setting.Name, value)
+	case SettingUsername:
+		return validUsername(setting.Name, value)
+	default:
+		_, err := setting.Parse(value)
+		return err
+	}
+}
+
+// Parse returns the typed value of the setting: an int, bool, time.Duration,
+// float64, *Username, []string or string depending on its type. Empty values
+// are parsed to the zero value of the type.
+func (setting Setting) Parse(value string) (interface{}, error) {
+	if len(value) > 0 && len(setting.Values) > 0 && !slices.Contains(setting.Values, value) {
+		return nil, setting.invalidValue(value)
+	}
+
+	switch setting.Type {
+	case SettingInt:
+		if len(value) == 0 {
+			return 0, nil
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, setting.invalidValue(value)
+		}
+		return parsed, nil
+	case SettingBool:
+		if len(value) == 0 {
+			return false, nil
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, setting.invalidValue(value)
+		}
+		return parsed, nil
+	case SettingDuration:
+		if len(value) == 0 {
+			return time.Duration(0), nil
+		}
+		return durationFromString(setting.Name, value)
+	case SettingFraction:
+		if len(value) == 0 {
+			return float64(0), nil
+		}
+		if err := validFraction(setting.Name, value); err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(value, 64)
+	case SettingUsername:
+		if len(value) == 0 {
+			return (*Username)(nil), nil
+		}
+		return NewUsername(value)
+	case SettingList:
+		return ParseList(value), nil
+	case SettingTLSVersion:
+		switch value {
+		case "":
+			return uint16(0), nil
+		case "1.2":
+			return uint16(tls.VersionTLS12), nil
+		case "1.3":
+			return uint16(tls.VersionTLS13), nil
+		default:
+			return nil, setting.invalidValue(value)
+		}
+	case SettingHeaders:
+		headers, err := parseHeaders(value)
+		if err != nil {
+			return nil, fmt.Errorf(log.CAKC154, setting.Name, err)
+		}
+		return headers, nil
+	case SettingSPKIPins:
+		pins := ParseList(value)
+		for _, pin := range pins {
+			if !validSPKIPin(pin) {
+				return nil, setting.invalidValue(value)
+			}
+		}
+		return pins, nil
+	default:
+		return value, nil
+	}
+}
+
+// invalidValue returns the error reporting an invalid value of the setting.
+// Sensitive values are redacted.
+func (setting Setting) invalidValue(value string) error {
+	return fmt.Errorf(log.CAKC060, setting.Name, setting.Redact(value))
+}
+
+// Redact replaces the value of a sensitive setting with the SHA-256
+// fingerprint of the certificate it holds, or of the value itself
+func (setting Setting) Redact(value string) string {
+	if !setting.Sensitive || value == "" {
+		return value
+	}
+
+	data := []byte(value)
+	if block, _ := pem.Decode(data); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			data = cert.Raw
+		}
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ParseList splits a comma-separated setting value, dropping empty items
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,164 @@
+package common
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsRegistry(t *testing.T) {
+	names := []string{}
+	for _, setting := range Settings() {
+		names = append(names, setting.Name)
+		assert.NotEmpty(t, setting.Type, setting.Name)
+		assert.NotEmpty(t, setting.Description, setting.Name)
+		assert.NotEmpty(t, setting.AuthnTypes, setting.Name)
+		for _, authnType := range setting.RequiredBy {
+			assert.Contains(t, setting.AuthnTypes, authnType, setting.Name)
+		}
+		// Writable paths are checked against the filesystem
+		if setting.Default != "" && setting.Type != SettingWritablePath {
+			assert.NoError(t, setting.Validate(setting.Default), setting.Name)
+		}
+	}
+
+	assert.True(t, sort.StringsAreSorted(names))
+	assert.Len(t, names, len(settingsRegistry))
+	for i := 1; i < len(names); i++ {
+		assert.NotEqual(t, names[i-1], names[i])
+	}
+
+	assert.Equal(
+		t,
+		[]string{"CONJUR_ACCOUNT", "CONJUR_AUTHN_LOGIN", "CONJUR_AUTHN_URL", "MY_POD_NAME", "MY_POD_NAMESPACE"},
+		RequiredSettingNames(AuthnTypeK8s),
+	)
+	assert.Equal(t, []string{"CONJUR_ACCOUNT", "CONJUR_AUTHN_URL"}, RequiredSettingNames(AuthnTypeJWT))
+	assert.Contains(t, SettingNames(AuthnTypeJWT), "JWT_TOKEN_SOURCE")
+	assert.NotContains(t, SettingNames(AuthnTypeJWT), "CONJUR_CLIENT_CERT_PATH")
+	assert.Equal(t, "file", DefaultSettingValues(AuthnTypeJWT)["JWT_TOKEN_SOURCE"])
+	assert.NotContains(t, DefaultSettingValues(AuthnTypeK8s), "JWT_TOKEN_SOURCE")
+}
+
+func TestSettingValidate(t *testing.T) {
+	TestCases := []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{name: "CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT", value: "7"},
+		{name: "CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT", value: "seven", err: "CAKC060 Setting CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT given invalid value seven"},
+		{name: "CONJUR_TOKEN_TIMEOUT", value: "seventeen", err: "CAKC060 Setting CONJUR_TOKEN_TIMEOUT given invalid value seventeen"},
+		{name: "CONJUR_TOKEN_TIMEOUT", value: ""},
+		{name: "CONJUR_STRICT_SETTINGS", value: "true"},
+		{name: "CONJUR_STRICT_SETTINGS", value: "sometimes", err: "CAKC060 Setting CONJUR_STRICT_SETTINGS given invalid value sometimes"},
+		{name: "CONJUR_CLIENT_CERT_RENEWAL_FRACTION", value: "1", err: "CAKC060 Setting CONJUR_CLIENT_CERT_RENEWAL_FRACTION given invalid value 1"},
+		{name: "MY_POD_IP", value: "10.0.0.300", err: "CAKC060 Setting MY_POD_IP given invalid value 10.0.0.300"},
+		{name: "JWT_OIDC_TOKEN_URL", value: "not a url", err: "CAKC060 Setting JWT_OIDC_TOKEN_URL given invalid value not a url"},
+		{name: "CONJUR_AUTHN_LOGIN", value: "bad-username", err: "CAKC032 CONJUR_AUTHN_LOGIN bad-username must start with 'host/'"},
+		{name: "CONJUR_CLIENT_KEY_ALGORITHM", value: "ecdsa-p384"},
+		{name: "CONJUR_CLIENT_KEY_ALGORITHM", value: "ecdsa-p265", err: "CAKC060 Setting CONJUR_CLIENT_KEY_ALGORITHM given invalid value ecdsa-p265"},
+		{name: "CONJUR_CLIENT_KEY_PROVIDER", value: "pkcs12", err: "CAKC060 Setting CONJUR_CLIENT_KEY_PROVIDER given invalid value pkcs12"},
+		{name: "CONJUR_TLS_MIN_VERSION", value: "1.1", err: "CAKC060 Setting CONJUR_TLS_MIN_VERSION given invalid value 1.1"},
+		{name: "UNKNOWN_SETTING", value: "anything"},
+	}
+
+	for _, tc := range TestCases {
+		t.Run(tc.name+"="+tc.value, func(t *testing.T) {
+			err := ValidateSetting(tc.name, tc.value)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestSensitiveSettingErrors(t *testing.T) {
+	setting := Setting{Name: "SECRET_COUNT", Type: SettingInt, Sensitive: true}
+	_, err := setting.Parse("hello")
+	assert.EqualError(
+		t,
+		err,
+		"CAKC060 Setting SECRET_COUNT given invalid value sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	)
+}
+
+func TestLoadSettings(t *testing.T) {
+	type nested struct {
+		Sources []string `setting:"JWT_TOKEN_SOURCE"`
+	}
+	type target struct {
+		Common   Config
+		Fraction float64 `setting:"CONJUR_CLIENT_CERT_RENEWAL_FRACTION"`
+		Strict   bool    `setting:"CONJUR_STRICT_SETTINGS"`
+		Nested   nested
+		Other    string
+	}
+
+	t.Run("typed values", func(t *testing.T) {
+		var loaded target
+		loaded.Other = "unchanged"
+		err := LoadSettings(&loaded, map[string]string{
+			"CONJUR_ACCOUNT":                       "testAccount",
+			"CONJUR_AUTHN_LOGIN":                   "host/apps/test-app",
+			"CONJUR_SSL_CERTIFICATE":               "testSSLCert",
+			"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT": "7",
+			"CONJUR_TOKEN_TIMEOUT":                 "3m0s",
+			"CONJUR_CLIENT_CERT_RENEWAL_FRACTION":  "0.5",
+			"CONJUR_STRICT_SETTINGS":               "true",
+			"JWT_TOKEN_SOURCE":                     "env, file",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "testAccount", loaded.Common.Account)
+		assert.Equal(t, "host.apps", loaded.Common.Username.Prefix)
+		assert.Equal(t, []byte("testSSLCert"), loaded.Common.SSLCertificate)
+		assert.Equal(t, 7, loaded.Common.ClientCertRetryCountLimit)
+		assert.Equal(t, 3*time.Minute, loaded.Common.TokenRefreshTimeout)
+		assert.Equal(t, 0.5, loaded.Fraction)
+		assert.True(t, loaded.Strict)
+		assert.Equal(t, []string{"env", "file"}, loaded.Nested.Sources)
+		assert.Equal(t, "unchanged", loaded.Other)
+	})
+
+	t.Run("empty values", func(t *testing.T) {
+		var loaded target
+		err := LoadSettings(&loaded, map[string]string{
+			"CONJUR_AUTHN_LOGIN":                   "",
+			"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT": "",
+			"JWT_TOKEN_SOURCE":                     "",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, loaded.Common.Username)
+		assert.Equal(t, 0, loaded.Common.ClientCertRetryCountLimit)
+		assert.Empty(t, loaded.Nested.Sources)
+	})
+
+	t.Run("parse errors are returned", func(t *testing.T) {
+		var config Config
+		err := config.LoadConfig(map[string]string{"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT": "seven"})
+		assert.EqualError(t, err, "CAKC060 Setting CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT given invalid value seven")
+	})
+
+	t.Run("unregistered setting", func(t *testing.T) {
+		var loaded struct {
+			Value string `setting:"UNKNOWN_SETTING"`
+		}
+		err := LoadSettings(&loaded, map[string]string{"UNKNOWN_SETTING": "value"})
+		assert.EqualError(t, err, "setting UNKNOWN_SETTING of field Value is not registered")
+	})
+
+	t.Run("mismatched field type", func(t *testing.T) {
+		var loaded struct {
+			Value int `setting:"CONJUR_ACCOUNT"`
+		}
+		err := LoadSettings(&loaded, map[string]string{"CONJUR_ACCOUNT": "testAccount"})
+		assert.EqualError(t, err, "setting CONJUR_ACCOUNT cannot be loaded into field Value of type int")
+	})
+}
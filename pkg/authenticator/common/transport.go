@@ -0,0 +1,172 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// Default timeouts of the Conjur requests
+const (
+	DefaultConnectTimeout      = 10 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+	DefaultHTTPTimeout         = 10 * time.Second
+)
+
+// TransportConfig tunes the HTTP client sending the Conjur requests. Zero
+// values select the defaults.
+type TransportConfig struct {
+	ConnectTimeout      time.Duration `setting:"CONJUR_CONNECT_TIMEOUT"`
+	TLSHandshakeTimeout time.Duration `setting:"CONJUR_TLS_HANDSHAKE_TIMEOUT"`
+	Timeout             time.Duration `setting:"CONJUR_HTTP_TIMEOUT"`
+	TLSMinVersion       uint16        `setting:"CONJUR_TLS_MIN_VERSION"`
+	TLSServerName       string        `setting:"CONJUR_TLS_SERVER_NAME"`
+	// ProxyURL replaces the HTTPS_PROXY and NO_PROXY environment variables.
+	// The certificate of an https proxy is verified with ProxyCAFile, or the
+	// system roots if it is empty.
+	ProxyURL    string            `setting:"CONJUR_PROXY_URL"`
+	ProxyCAFile string            `setting:"CONJUR_PROXY_CA_FILE"`
+	Headers     map[string]string `setting:"CONJUR_HTTP_HEADERS"`
+}
+
+// headerName matches the token characters of HTTP header names
+var headerName = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// parseHeaders parses a comma-separated list of Name=value headers with
+// percent-encoded values, as in OTEL_EXPORTER_OTLP_HEADERS. The errors never
+// include the values, which may be secrets.
+func parseHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	for i, item := range ParseList(value) {
+		name, encoded, found := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !found || !headerName.MatchString(name) {
+			return nil, fmt.Errorf("header %d has no valid name", i+1)
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(encoded))
+		if err != nil || strings.ContainsAny(decoded, "\r\n") {
+			return nil, fmt.Errorf("header %s has an invalid value", name)
+		}
+		headers[http.CanonicalHeaderKey(name)] = decoded
+	}
+	return headers, nil
+}
+
+// SetHeaders adds the extra headers to a Conjur request, without replacing
+// the headers set by the client
+func SetHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
+func (config TransportConfig) withDefaults() TransportConfig {
+	if config.ConnectTimeout == 0 {
+		config.ConnectTimeout = DefaultConnectTimeout
+	}
+	if config.TLSHandshakeTimeout == 0 {
+		config.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultHTTPTimeout
+	}
+	return config
+}
+
+// applyTLS sets the TLS settings up in the TLS config of the Conjur
+// connections
+func (config TransportConfig) applyTLS(tlsConfig *tls.Config) {
+	if config.TLSMinVersion != 0 {
+		tlsConfig.MinVersion = config.TLSMinVersion
+	}
+	if config.TLSServerName != "" {
+		tlsConfig.ServerName = config.TLSServerName
+	}
+}
+
+// newTransport returns the base transport of the Conjur connections, without
+// TLS config. The config must have its defaults applied.
+func (config TransportConfig) newTransport() (*http.Transport, error) {
+	proxy, dial, err := config.proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep connections alive so that consecutive requests reuse them
+	return &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dial,
+		TLSHandshakeTimeout: config.TLSHandshakeTimeout,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	}, nil
+}
+
+// proxy returns the proxy function of the transport, and the dial function
+// connecting to the proxy when it is an https proxy. The transport then sends
+// its CONNECT requests to the proxy over the TLS connection.
+func (config TransportConfig) proxy() (
+	func(*http.Request) (*url.URL, error),
+	func(ctx context.Context, network, address string) (net.Conn, error),
+	error,
+) {
+	dialer := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second}
+	if config.ProxyURL == "" {
+		return http.ProxyFromEnvironment, dialer.DialContext, nil
+	}
+
+	proxyURL, err := url.Parse(config.ProxyURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proxyURL.Scheme != "https" {
+		return http.ProxyURL(proxyURL), dialer.DialContext, nil
+	}
+
+	var pool *x509.CertPool
+	if config.ProxyCAFile != "" {
+		pem, err := ReadCABundle(config.ProxyCAFile, os.ReadFile)
+		if err != nil {
+			return nil, nil, log.RecordedError(log.CAKC152, config.ProxyCAFile, err)
+		}
+		pool = x509.NewCertPool()
+		pool.AppendCertsFromPEM(pem)
+	}
+
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "443")
+	}
+	httpProxyURL := *proxyURL
+	httpProxyURL.Scheme = "http"
+	httpProxyURL.Host = proxyAddress
+
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil || address != proxyAddress {
+			return conn, err
+		}
+
+		tlsConn := tls.Client(conn, &tls.Config{RootCAs: pool, ServerName: proxyURL.Hostname()})
+		handshakeCtx, cancel := context.WithTimeout(ctx, config.TLSHandshakeTimeout)
+		defer cancel()
+		if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+			conn.Close()
+			return nil, log.RecordedError(log.CAKC153, proxyURL.Redacted(), err)
+		}
+		return tlsConn, nil
+	}
+	return http.ProxyURL(&httpProxyURL), dial, nil
+}
@@ -0,0 +1,207 @@
+package common
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders("x-gateway-key=abc%2C123, X-Team = payments%20team")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Gateway-Key": "abc,123", "X-Team": "payments team"}, headers)
+
+	// The values are secrets, so only the headers are named
+	for invalid, reason := range map[string]string{
+		"s3cr3t":                   "header 1 has no valid name",
+		"X-Team=a, X Key=s3cr3t":   "header 2 has no valid name",
+		"X-Key=s3cr%ZZ":            "header X-Key has an invalid value",
+		"X-Team=a, X-Key=s3cr%0At": "header X-Key has an invalid value",
+	} {
+		assert.EqualError(
+			t,
+			ValidateSetting("CONJUR_HTTP_HEADERS", invalid),
+			"CAKC154 Setting CONJUR_HTTP_HEADERS given invalid headers. Reason: "+reason,
+		)
+	}
+	assert.EqualError(t, ValidateSetting("CONJUR_TLS_MIN_VERSION", "1.1"), "CAKC060 Setting CONJUR_TLS_MIN_VERSION given invalid value 1.1")
+}
+
+func TestSetHeaders(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://conjur", nil)
+	req.Header.Set("Content-Type", "text/plain")
+
+	SetHeaders(req, map[string]string{"Content-Type": "application/json", "X-Gateway-Key": "abc"})
+	assert.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+	assert.Equal(t, "abc", req.Header.Get("X-Gateway-Key"))
+}
+
+func TestLoadTransportConfig(t *testing.T) {
+	var config Config
+	err := config.LoadConfig(map[string]string{
+		"CONJUR_CONNECT_TIMEOUT":       "2s",
+		"CONJUR_TLS_HANDSHAKE_TIMEOUT": "3s",
+		"CONJUR_HTTP_TIMEOUT":          "30s",
+		"CONJUR_TLS_MIN_VERSION":       "1.3",
+		"CONJUR_TLS_SERVER_NAME":       "conjur.example.com",
+		"CONJUR_PROXY_URL":             "https://proxy:3128",
+		"CONJUR_HTTP_HEADERS":          "X-Gateway-Key=abc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TransportConfig{
+		ConnectTimeout:      2 * time.Second,
+		TLSHandshakeTimeout: 3 * time.Second,
+		Timeout:             30 * time.Second,
+		TLSMinVersion:       tls.VersionTLS13,
+		TLSServerName:       "conjur.example.com",
+		ProxyURL:            "https://proxy:3128",
+		Headers:             map[string]string{"X-Gateway-Key": "abc"},
+	}, config.Transport)
+}
+
+func TestTransportConfig(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	TestCases := []struct {
+		description string
+		transport   TransportConfig
+		url         string
+		err         string
+	}{
+		{
+			description: "defaults",
+			url:         server.URL,
+		},
+		{
+			description: "minimum TLS version",
+			transport:   TransportConfig{TLSMinVersion: tls.VersionTLS13},
+			url:         server.URL,
+			err:         "protocol version not supported",
+		},
+		{
+			description: "server name override",
+			transport:   TransportConfig{TLSServerName: "example.com"},
+			url:         "https://localhost:" + port,
+		},
+		{
+			description: "certificate name mismatch",
+			url:         "https://localhost:" + port,
+			err:         "certificate is valid for",
+		},
+		{
+			description: "timeout",
+			transport:   TransportConfig{Timeout: 50 * time.Millisecond},
+			url:         server.URL + "/slow",
+			err:         "Client.Timeout exceeded",
+		},
+	}
+
+	for _, tc := range TestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			bundle, err := NewCABundle(serverCA, "", false)
+			if !assert.NoError(t, err) {
+				return
+			}
+			client, err := NewHTTPSClientWithCABundle(bundle, tc.transport, nil, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp, err := client.Get(tc.url)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestHTTPSProxy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	var connects atomic.Int32
+	proxy := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		connects.Add(1)
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buffered, _ := w.(http.Hijacker).Hijack()
+		go func() {
+			io.Copy(target, buffered)
+			target.Close()
+		}()
+		io.Copy(conn, target)
+		conn.Close()
+	}))
+	defer proxy.Close()
+
+	proxyCAFile := filepath.Join(t.TempDir(), "proxy.pem")
+	proxyCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: proxy.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(proxyCAFile, proxyCA, 0644))
+
+	bundle, err := NewCABundle(serverCA, "", false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("proxy CA", func(t *testing.T) {
+		transport := TransportConfig{ProxyURL: proxy.URL, ProxyCAFile: proxyCAFile}
+		client, err := NewHTTPSClientWithCABundle(bundle, transport, nil, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+		assert.Equal(t, int32(1), connects.Load())
+	})
+
+	t.Run("untrusted proxy", func(t *testing.T) {
+		client, err := NewHTTPSClientWithCABundle(bundle, TransportConfig{ProxyURL: proxy.URL}, nil, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = client.Get(server.URL)
+		assert.ErrorContains(t, err, "CAKC153 Failed to connect to the proxy "+proxy.URL)
+	})
+
+	t.Run("invalid proxy CA", func(t *testing.T) {
+		transport := TransportConfig{ProxyURL: proxy.URL, ProxyCAFile: filepath.Join(t.TempDir(), "missing.pem")}
+		_, err := NewHTTPSClientWithCABundle(bundle, transport, nil, nil)
+		assert.ErrorContains(t, err, "CAKC152 Failed to read the proxy CA certificates")
+	})
+}
@@ -4,8 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 )
@@ -13,14 +18,40 @@ import (
 // ReadFileFunc defines the interface for reading an SSL Certificate from the env
 type ReadFileFunc func(filename string) ([]byte, error)
 
-func validTimeout(key, timeoutStr string) error {
-	_, err := durationFromString(key, timeoutStr)
-	return err
+func validFraction(key, value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	fraction, err := strconv.ParseFloat(value, 64)
+	if err != nil || fraction < 0 || fraction >= 1 {
+		return fmt.Errorf(log.CAKC060, key, value)
+	}
+	return nil
 }
 
-func validInt(key, value string) error {
-	_, err := strconv.Atoi(value)
-	if err != nil {
+func validIP(key, value string) error {
+	if len(value) == 0 || net.ParseIP(value) != nil {
+		return nil
+	}
+	return fmt.Errorf(log.CAKC060, key, value)
+}
+
+func validTrustDomain(key, value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	if _, err := spiffeid.TrustDomainFromString(value); err != nil {
+		return fmt.Errorf(log.CAKC060, key, value)
+	}
+	return nil
+}
+
+func validURL(key, value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
 		return fmt.Errorf(log.CAKC060, key, value)
 	}
 	return nil
@@ -30,25 +61,38 @@ func validUsername(key, value string) error {
 	if len(value) == 0 {
 		return nil
 	}
-	_, err := NewUsername(value)
-	return err
+	if placeholder := unresolvedLoginPlaceholder(value); placeholder != "" {
+		return fmt.Errorf(log.CAKC133, placeholder)
+	}
+	// Checked like NewUsername does, without logging the error
+	if strings.Split(value, "/")[0] != "host" {
+		return fmt.Errorf(log.CAKC032, value)
+	}
+	return nil
 }
 
+// ValidateSetting checks that a value is valid for the setting with the given
+// name, according to its type in the settings registry
 func ValidateSetting(key string, value string) error {
-	switch key {
-	case "CONJUR_AUTHN_LOGIN":
-		return validUsername(key, value)
-	case "CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT":
-		return validInt(key, value)
-	case "CONJUR_TOKEN_TIMEOUT":
-		return validTimeout(key, value)
-	case "JWT_TOKEN_PATH":
-		return validatePath(value)
-	default:
+	setting, ok := LookupSetting(key)
+	if !ok {
+		return nil
+	}
+	return setting.Validate(value)
+}
+
+// CheckSetting checks a value like ValidateSetting, without accessing the
+// filesystem
+func CheckSetting(key string, value string) error {
+	setting, ok := LookupSetting(key)
+	if !ok {
 		return nil
 	}
+	return setting.Check(value)
 }
 
+// ReadSSLCert returns CONJUR_SSL_CERTIFICATE, or the certificates of the
+// CONJUR_CERT_FILE file or directory
 func ReadSSLCert(settings map[string]string, readFile ReadFileFunc) ([]byte, error) {
 	SSLCert := settings["CONJUR_SSL_CERTIFICATE"]
 	SSLCertPath := settings["CONJUR_CERT_FILE"]
@@ -59,7 +103,7 @@ func ReadSSLCert(settings map[string]string, readFile ReadFileFunc) ([]byte, err
 	if SSLCert != "" {
 		return []byte(SSLCert), nil
 	}
-	return readFile(SSLCertPath)
+	return ReadCABundle(SSLCertPath, readFile)
 }
 
 func validatePath(path string) error {
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// annotationsFileVarName is the setting holding the path of the pod
+// annotations file written by a downward API volume
+const annotationsFileVarName string = "CONJUR_ANNOTATIONS_FILE"
+
+// annotationSettings maps the pod annotations read by the authenticator to
+// the settings they configure. Other annotations, including other conjur.org/
+// ones used by e.g. the Secrets Provider, are ignored.
+var annotationSettings = map[string]string{
+	"conjur.org/authn-identity": "CONJUR_AUTHN_LOGIN",
+	"conjur.org/container-mode": "CONTAINER_MODE",
+	"conjur.org/debug-logging":  "DEBUG",
+	"conjur.org/jwt-token-path": "JWT_TOKEN_PATH",
+	"conjur.org/log-level":      "LOG_LEVEL",
+}
+
+// NewAnnotationsGetter returns a getter for GatherSettings with the settings
+// configured by the conjur.org/ annotations in a downward API annotations
+// file, e.g. CONTAINER_MODE for conjur.org/container-mode
+func NewAnnotationsGetter(path string, readFileFunc common.ReadFileFunc) (func(key string) string, error) {
+	settings, err := readAnnotationsFile(path, readFileFunc)
+	if err != nil {
+		return nil, err
+	}
+	return settings.get, nil
+}
+
+// readAnnotationsFile parses a downward API annotations file, in which each
+// line holds an annotation as name="value", with the value quoted and escaped
+// as a Go string
+func readAnnotationsFile(path string, readFileFunc common.ReadFileFunc) (AuthnSettings, error) {
+	content, err := readFileFunc(path)
+	if err != nil {
+		return nil, log.RecordedError(log.CAKC138, path, err)
+	}
+
+	settings := make(AuthnSettings)
+	for number, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		name, quoted, found := strings.Cut(line, "=")
+		if !found {
+			return nil, log.RecordedError(log.CAKC138, path, fmt.Sprintf("line %d is not an annotation", number+1))
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, log.RecordedError(log.CAKC138, path, fmt.Sprintf("line %d has an invalid value", number+1))
+		}
+
+		if key, ok := annotationSettings[name]; ok {
+			settings[key] = value
+		}
+	}
+
+	log.Info(log.CAKC139, path)
+	return settings, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAnnotations = `conjur.org/authn-identity="host/conjur/authn-k8s/my-authenticator-id/apps/test-app"
+conjur.org/container-mode="init"
+conjur.org/secrets-destination="file"
+kubernetes.io/config.seen="2026-10-19T10:00:00.000000000Z"
+conjur.org/jwt-token-path="/var/run/secrets/tokens/jwt"
+`
+
+func writeAnnotations(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "annotations")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestNewAnnotationsGetter(t *testing.T) {
+	t.Run("maps conjur.org annotations to settings", func(t *testing.T) {
+		getAnnotation, err := NewAnnotationsGetter(writeAnnotations(t, testAnnotations), os.ReadFile)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "host/conjur/authn-k8s/my-authenticator-id/apps/test-app", getAnnotation("CONJUR_AUTHN_LOGIN"))
+		assert.Equal(t, "init", getAnnotation("CONTAINER_MODE"))
+		assert.Equal(t, "/var/run/secrets/tokens/jwt", getAnnotation("JWT_TOKEN_PATH"))
+		assert.Equal(t, "", getAnnotation("LOG_LEVEL"))
+	})
+
+	t.Run("unescapes values", func(t *testing.T) {
+		path := writeAnnotations(t, `conjur.org/authn-identity="host/apps/\"quoted\"\nvalue"`+"\n")
+		getAnnotation, err := NewAnnotationsGetter(path, os.ReadFile)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "host/apps/\"quoted\"\nvalue", getAnnotation("CONJUR_AUTHN_LOGIN"))
+	})
+
+	TestCases := []struct {
+		description string
+		content     string
+		err         string
+	}{
+		{
+			description: "line without a value",
+			content:     "conjur.org/container-mode\n",
+			err:         "line 1 is not an annotation",
+		},
+		{
+			description: "unquoted value",
+			content:     "conjur.org/log-level=\"debug\"\nconjur.org/container-mode=init\n",
+			err:         "line 2 has an invalid value",
+		},
+	}
+
+	for _, tc := range TestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := writeAnnotations(t, tc.content)
+			getAnnotation, err := NewAnnotationsGetter(path, os.ReadFile)
+			assert.EqualError(t, err, "CAKC138 Failed to read pod annotations file "+path+". Reason: "+tc.err)
+			assert.Nil(t, getAnnotation)
+		})
+	}
+}
+
+func TestAnnotationsPrecedence(t *testing.T) {
+	env := map[string]string{
+		"CONJUR_AUTHN_URL":        "authn-jwt",
+		"CONJUR_ACCOUNT":          "testAccount",
+		"CONJUR_SSL_CERTIFICATE":  "samplecertificate",
+		"CONTAINER_MODE":          "sidecar",
+		annotationsFileVarName:    writeAnnotations(t, "conjur.org/container-mode=\"init\"\n"),
+		"CONJUR_TOKEN_TIMEOUT":    "3m0s",
+		"JWT_TOKEN_PATH":          "/tmp/token",
+		"CONJUR_AUTHN_TOKEN_FILE": "/run/conjur/access-token",
+	}
+	getEnv := func(key string) string {
+		return env[key]
+	}
+
+	configObj, err := NewConfigFromCustomEnv(os.ReadFile, getEnv)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The annotation takes precedence over the environment variable
+	assert.Equal(t, "init", configObj.GetContainerMode())
+	assert.Equal(t, "/run/conjur/access-token", configObj.GetTokenFilePath())
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	jwtAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/jwt"
+	k8sAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/k8s"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// ValidationErrors holds every error found while building a configuration
+type ValidationErrors struct {
+	Errors []error
+}
+
+func (err *ValidationErrors) Error() string {
+	messages := make([]string, len(err.Errors))
+	for i, e := range err.Errors {
+		messages[i] = e.Error()
+	}
+	return log.CAKC061 + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the validation errors, for errors.Is and errors.As
+func (err *ValidationErrors) Unwrap() []error {
+	return err.Errors
+}
+
+// Option sets up a configuration built by New
+type Option func(builder *builder)
+
+type builder struct {
+	settings     AuthnSettings
+	readFileFunc common.ReadFileFunc
+}
+
+// WithSetting sets a setting by name, e.g. CONJUR_AUTHN_LOGIN. An empty value
+// leaves the setting to its default.
+func WithSetting(name, value string) Option {
+	return func(builder *builder) {
+		builder.settings[name] = value
+	}
+}
+
+// WithSettings sets settings by name, like WithSetting
+func WithSettings(settings map[string]string) Option {
+	return func(builder *builder) {
+		for name, value := range settings {
+			builder.settings[name] = value
+		}
+	}
+}
+
+// WithAuthnURL sets CONJUR_AUTHN_URL
+func WithAuthnURL(url string) Option {
+	return WithSetting("CONJUR_AUTHN_URL", url)
+}
+
+// WithAccount sets CONJUR_ACCOUNT
+func WithAccount(account string) Option {
+	return WithSetting("CONJUR_ACCOUNT", account)
+}
+
+// WithLogin sets CONJUR_AUTHN_LOGIN
+func WithLogin(login string) Option {
+	return WithSetting("CONJUR_AUTHN_LOGIN", login)
+}
+
+// WithSSLCertificate sets CONJUR_SSL_CERTIFICATE to a PEM encoded certificate
+func WithSSLCertificate(cert []byte) Option {
+	return WithSetting("CONJUR_SSL_CERTIFICATE", string(cert))
+}
+
+// WithTokenTimeout sets CONJUR_TOKEN_TIMEOUT
+func WithTokenTimeout(timeout time.Duration) Option {
+	return WithSetting("CONJUR_TOKEN_TIMEOUT", timeout.String())
+}
+
+// WithReadFileFunc sets the function reading CONJUR_CERT_FILE and the files of
+// the pod info directory. Without it, New does not read any file.
+func WithReadFileFunc(readFileFunc common.ReadFileFunc) Option {
+	return func(builder *builder) {
+		builder.readFileFunc = readFileFunc
+	}
+}
+
+// New builds and validates the configuration of the authn-k8s or authn-jwt
+// authenticator from the given options and the setting defaults. Unlike
+// NewConfigFromEnv, it does not read the environment, set the log level, log,
+// or check that paths are writable. Files are only read through the given file
+// reader, with two exceptions: a CONJUR_CERT_FILE or CONJUR_PROXY_CA_FILE
+// directory, which the reader reports with syscall.EISDIR, is listed with
+// os.ReadDir before its files are read through the reader, and CONJUR_CERT_FILE
+// is looked up with os.Stat when CONJUR_SSL_BOOTSTRAP is set. All the problems
+// found are returned as a *ValidationErrors.
+func New(authnType string, options ...Option) (Configuration, error) {
+	var conf Configuration
+	switch authnType {
+	case common.AuthnTypeK8s:
+		conf = &k8sAuthenticator.Config{}
+	case common.AuthnTypeJWT:
+		conf = &jwtAuthenticator.Config{}
+	default:
+		return nil, fmt.Errorf(log.CAKC060, "authenticator", authnType)
+	}
+
+	builder := &builder{settings: AuthnSettings{}}
+	for _, option := range options {
+		option(builder)
+	}
+
+	errs := []error{}
+	for _, name := range builder.unusedSettings(authnType) {
+		if _, ok := common.LookupSetting(name); ok {
+			errs = append(errs, fmt.Errorf(log.CAKC137, name, "the options"))
+		} else {
+			errs = append(errs, unknownSettingError(name, "the options"))
+		}
+	}
+
+	defaults := conf.GetDefaultValues()
+	settings := make(AuthnSettings)
+	for _, key := range conf.GetEnvVariables() {
+		settings[key] = builder.settings[key]
+		if settings[key] == "" {
+			settings[key] = defaults[key]
+		}
+	}
+	if url := settings[authnURLVarName]; url != "" && !strings.Contains(url, authnType) {
+		errs = append(errs, fmt.Errorf(log.CAKC063, url))
+	}
+	if login := settings["CONJUR_AUTHN_LOGIN"]; login != "" {
+		settings["CONJUR_AUTHN_LOGIN"] = common.ExpandLoginWithReader(login, settings.get, builder.readFileFunc)
+	}
+
+	errs = append(errs, settings.validateWith(conf, builder.readFile, common.CheckSetting)...)
+	if len(errs) > 0 {
+		return nil, &ValidationErrors{Errors: errs}
+	}
+
+	if err := conf.LoadConfig(settings); err != nil {
+		return nil, &ValidationErrors{Errors: []error{err}}
+	}
+	return conf, nil
+}
+
+// unusedSettings returns the given settings that the authenticator does not use
+func (builder *builder) unusedSettings(authnType string) []string {
+	names := []string{}
+	for name := range builder.settings {
+		if setting, ok := common.LookupSetting(name); !ok || !setting.UsedBy(authnType) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (builder *builder) readFile(path string) ([]byte, error) {
+	if builder.readFileFunc == nil {
+		return nil, fmt.Errorf(log.CAKC144, path)
+	}
+	return builder.readFileFunc(path)
+}
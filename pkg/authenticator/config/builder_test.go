@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	jwtAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/jwt"
+	k8sAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/k8s"
+	logger "github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// testCertificate is a self-signed CA certificate for conjur-ca
+const testCertificate = `-----BEGIN CERTIFICATE-----
+MIIBRzCB76ADAgECAgEBMAoGCCqGSM49BAMCMBQxEjAQBgNVBAMTCWNvbmp1ci1j
+YTAeFw0yNjAxMDEwMDAwMDBaFw0zNjAxMDEwMDAwMDBaMBQxEjAQBgNVBAMTCWNv
+bmp1ci1jYTBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABGpESGudtM7PZTgc4Tk9
+EVdf4rx3L5xSs/h2UNvNWQdvtg6oIhu/vcnm40S1DajShTIwyZe0YpSi3i5zdjtk
+9h2jMjAwMA8GA1UdEwEB/wQFMAMBAf8wHQYDVR0OBBYEFBr4IqS9MsEfsLo9y4Pg
++70X8PbgMAoGCCqGSM49BAMCA0cAMEQCICaLEvpTQyUD4h92OFa107xf27QpqJ29
++baGyAFZICibAiBB8AcM9hnOLmAQ5ynOdkkrokz4aS5CtjXs3xd6ylAL6g==
+-----END CERTIFICATE-----
+`
+
+func TestNew(t *testing.T) {
+	t.Run("authn-k8s", func(t *testing.T) {
+		conf, err := New(
+			common.AuthnTypeK8s,
+			WithAuthnURL("https://conjur/authn-k8s/my-authenticator-id"),
+			WithAccount("testAccount"),
+			WithLogin("host/apps/${namespace}/test-app"),
+			WithSSLCertificate([]byte("samplecertificate")),
+			WithTokenTimeout(3*time.Minute),
+			WithSettings(map[string]string{
+				"MY_POD_NAME":      "testPodName",
+				"MY_POD_NAMESPACE": "testNamespace",
+			}),
+		)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		k8sConfig := conf.(*k8sAuthenticator.Config)
+		assert.Equal(t, "testAccount", k8sConfig.Common.Account)
+		assert.Equal(t, "host/apps/testNamespace/test-app", k8sConfig.Common.Username.FullUsername)
+		assert.Equal(t, []byte("samplecertificate"), k8sConfig.Common.SSLCertificate)
+		assert.Equal(t, 3*time.Minute, k8sConfig.GetTokenTimeout())
+		assert.Equal(t, k8sAuthenticator.DefaultClientCertPath, k8sConfig.Common.ClientCertPath)
+		assert.Equal(t, k8sAuthenticator.DefaultKeyAlgorithm, k8sConfig.KeyAlgorithm)
+	})
+
+	t.Run("no side effects", func(t *testing.T) {
+		dir := t.TempDir()
+		tokenPath := filepath.Join(dir, "missing", "jwt")
+		logOutput := &bytes.Buffer{}
+		logger.InfoLogger.SetOutput(logOutput)
+		logger.ErrorLogger.SetOutput(logOutput)
+		defer logger.InfoLogger.SetOutput(os.Stdout)
+		defer logger.ErrorLogger.SetOutput(os.Stderr)
+
+		conf, err := New(
+			common.AuthnTypeJWT,
+			WithAuthnURL("https://conjur/authn-jwt/my-authenticator-id"),
+			WithAccount("testAccount"),
+			WithSSLCertificate([]byte("samplecertificate")),
+			WithSetting("JWT_TOKEN_PATH", tokenPath),
+			WithSetting("LOG_LEVEL", "debug"),
+		)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, tokenPath, conf.(*jwtAuthenticator.Config).JWTTokenFilePath)
+		assert.NoFileExists(t, tokenPath)
+		assert.Empty(t, logOutput.String())
+
+		logger.Debug("debug message")
+		assert.Empty(t, logOutput.String())
+	})
+
+	t.Run("certificate file", func(t *testing.T) {
+		options := []Option{
+			WithAuthnURL("https://conjur/authn-jwt/my-authenticator-id"),
+			WithAccount("testAccount"),
+			WithSetting("CONJUR_CERT_FILE", "/etc/conjur/ssl/conjur.pem"),
+		}
+
+		_, err := New(common.AuthnTypeJWT, options...)
+		var validationErrs *ValidationErrors
+		if assert.ErrorAs(t, err, &validationErrs) {
+			assert.EqualError(t, validationErrs.Errors[0], "CAKC144 Cannot read file /etc/conjur/ssl/conjur.pem, no file reader was given to the configuration builder")
+		}
+
+		readFile := func(path string) ([]byte, error) {
+			return []byte(testCertificate), nil
+		}
+		conf, err := New(common.AuthnTypeJWT, append(options, WithReadFileFunc(readFile))...)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte(testCertificate), conf.(*jwtAuthenticator.Config).Common.SSLCertificate)
+			assert.Equal(t, "/etc/conjur/ssl/conjur.pem", conf.(*jwtAuthenticator.Config).Common.CertFile)
+		}
+	})
+
+	t.Run("certificate directory", func(t *testing.T) {
+		// The directory is listed directly, its files are read with the reader
+		dir := t.TempDir()
+		certFile := filepath.Join(dir, "conjur.pem")
+		for _, name := range []string{certFile, filepath.Join(dir, "README.txt")} {
+			if !assert.NoError(t, os.WriteFile(name, nil, 0644)) {
+				return
+			}
+		}
+		read := []string{}
+		readFile := func(path string) ([]byte, error) {
+			read = append(read, path)
+			if path == dir {
+				return nil, &os.PathError{Op: "read", Path: path, Err: syscall.EISDIR}
+			}
+			return []byte(testCertificate), nil
+		}
+
+		conf, err := New(
+			common.AuthnTypeJWT,
+			WithAuthnURL("https://conjur/authn-jwt/my-authenticator-id"),
+			WithAccount("testAccount"),
+			WithSetting("CONJUR_CERT_FILE", dir),
+			WithReadFileFunc(readFile),
+		)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte(testCertificate), conf.(*jwtAuthenticator.Config).Common.SSLCertificate)
+		}
+		assert.Equal(t, []string{dir, certFile}, read)
+	})
+
+	t.Run("all errors", func(t *testing.T) {
+		_, err := New(
+			common.AuthnTypeK8s,
+			WithAuthnURL("https://conjur/authn-jwt/my-authenticator-id"),
+			WithLogin("host/apps/${deployment}"),
+			WithSetting("CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT", "seven"),
+			WithSetting("JWT_TOKEN_SOURCE", "env"),
+			WithSetting("CONJUR_AUTHN_LOGN", "host/apps/test-app"),
+		)
+
+		var validationErrs *ValidationErrors
+		if !assert.ErrorAs(t, err, &validationErrs) {
+			return
+		}
+		assert.Equal(t, []string{
+			"CAKC142 Setting CONJUR_AUTHN_LOGN in the options is not used by the authenticator client, did you mean CONJUR_AUTHN_LOGIN?",
+			"CAKC137 Setting JWT_TOKEN_SOURCE in the options is not used by the authenticator client",
+			"CAKC063 Unable to find configuration for URL: https://conjur/authn-jwt/my-authenticator-id",
+			"CAKC062 Required Authenticator setting CONJUR_ACCOUNT not provided",
+			"CAKC062 Required Authenticator setting MY_POD_NAME not provided",
+			"CAKC062 Required Authenticator setting MY_POD_NAMESPACE not provided",
+			"CAKC133 Placeholder ${deployment} in CONJUR_AUTHN_LOGIN could not be resolved from the pod metadata",
+			"CAKC060 Setting CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT given invalid value seven",
+			"CAKC007 At least one of CONJUR_SSL_CERTIFICATE and CONJUR_CERT_FILE must be provided",
+		}, errorMessages(validationErrs.Errors))
+		assert.Contains(t, err.Error(), logger.CAKC061+": CAKC142 Setting CONJUR_AUTHN_LOGN")
+		assert.True(t, errors.Is(err, validationErrs.Errors[0]))
+	})
+
+	t.Run("unknown authenticator", func(t *testing.T) {
+		_, err := New("authn-iam")
+		assert.EqualError(t, err, "CAKC060 Setting authenticator given invalid value authn-iam")
+	})
+}
+
+func errorMessages(errs []error) []string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return messages
+}
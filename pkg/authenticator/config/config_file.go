@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// configFileVarName is the setting holding the path of the configuration file
+const configFileVarName string = "CONJUR_CONFIG_FILE"
+
+// readConfigFile reads settings from a YAML or JSON file mapping setting names,
+// e.g. CONJUR_ACCOUNT, to scalar values. Values are used as written in the
+// file, so that e.g. "0.70" or "6m0s" are not reformatted.
+func readConfigFile(path string, readFileFunc common.ReadFileFunc) (AuthnSettings, error) {
+	content, err := readFileFunc(path)
+	if err != nil {
+		return nil, log.RecordedError(log.CAKC135, path, err)
+	}
+
+	var nodes map[string]yaml.Node
+	if err := yaml.Unmarshal(content, &nodes); err != nil {
+		return nil, log.RecordedError(log.CAKC135, path, err)
+	}
+
+	settings := make(AuthnSettings)
+	for key, node := range nodes {
+		if node.Kind != yaml.ScalarNode {
+			return nil, log.RecordedError(
+				log.CAKC135,
+				path,
+				fmt.Sprintf("setting %s must be a string, number or boolean", key),
+			)
+		}
+		if node.Tag != "!!null" {
+			settings[key] = node.Value
+		}
+	}
+
+	log.Info(log.CAKC136, path)
+	return settings, nil
+}
+
+func (settings AuthnSettings) get(key string) string {
+	return settings[key]
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logger "github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFile(t *testing.T) {
+	TestCases := []struct {
+		description string
+		content     string
+		env         map[string]string
+		assert      configAssertFunc
+	}{
+		{
+			description: "settings read from a YAML file",
+			content: `CONJUR_AUTHN_URL: authn-jwt
+CONJUR_ACCOUNT: testAccount
+CONJUR_SSL_CERTIFICATE: samplecertificate
+JWT_TOKEN_PATH: /tmp/token
+CONJUR_TOKEN_TIMEOUT: 3m0s
+CONTAINER_MODE: init
+`,
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.NoError(t, err)
+				assert.Equal(t, "init", config.GetContainerMode())
+				assert.Equal(t, 3*time.Minute, config.GetTokenTimeout())
+				assert.Contains(t, logOutput, "CAKC136 Read settings from configuration file")
+			},
+		},
+		{
+			description: "settings read from a JSON file",
+			content: `{
+  "CONJUR_AUTHN_URL": "authn-jwt",
+  "CONJUR_ACCOUNT": "testAccount",
+  "CONJUR_SSL_CERTIFICATE": "samplecertificate",
+  "JWT_TOKEN_PATH": "/tmp/token",
+  "CONTAINER_MODE": "sidecar"
+}`,
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.NoError(t, err)
+				assert.Equal(t, "sidecar", config.GetContainerMode())
+			},
+		},
+		{
+			description: "environment variables take precedence over the file",
+			content: `CONJUR_AUTHN_URL: authn-jwt
+CONJUR_ACCOUNT: testAccount
+CONJUR_SSL_CERTIFICATE: samplecertificate
+JWT_TOKEN_PATH: /tmp/token
+CONTAINER_MODE: sidecar
+`,
+			env: map[string]string{"CONTAINER_MODE": "init"},
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.NoError(t, err)
+				assert.Equal(t, "init", config.GetContainerMode())
+			},
+		},
+		{
+			description: "unknown settings are reported",
+			content: `CONJUR_AUTHN_URL: authn-jwt
+CONJUR_ACCOUNT: testAccount
+CONJUR_SSL_CERTIFICATE: samplecertificate
+JWT_TOKEN_PATH: /tmp/token
+CONJUR_TOKEN_TIMOUT: 3m0s
+`,
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.NoError(t, err)
+				assert.Contains(t, logOutput, "CAKC142 Setting CONJUR_TOKEN_TIMOUT in configuration file")
+				assert.Contains(t, logOutput, "did you mean CONJUR_TOKEN_TIMEOUT?")
+			},
+		},
+		{
+			description: "nested values are rejected",
+			content: `CONJUR_AUTHN_URL: authn-jwt
+CONJUR_ACCOUNT:
+  name: testAccount
+`,
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.ErrorContains(t, err, "setting CONJUR_ACCOUNT must be a string, number or boolean")
+				assert.Nil(t, config)
+			},
+		},
+		{
+			description: "invalid file",
+			content:     "CONJUR_AUTHN_URL: [authn-jwt",
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.ErrorContains(t, err, "CAKC135 Failed to read configuration file")
+				assert.Nil(t, config)
+			},
+		},
+	}
+
+	for _, tc := range TestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			// SETUP
+			path := filepath.Join(t.TempDir(), "conjur.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+
+			env := map[string]string{configFileVarName: path}
+			for key, value := range tc.env {
+				env[key] = value
+			}
+			getEnv := func(key string) string {
+				return env[key]
+			}
+
+			// Intercept logger output
+			logOutput := io.ReadWriter(&bytes.Buffer{})
+			logger.ErrorLogger.SetOutput(logOutput)
+			logger.InfoLogger.SetOutput(logOutput)
+			defer logger.ErrorLogger.SetOutput(os.Stderr)
+			defer logger.InfoLogger.SetOutput(os.Stdout)
+
+			// EXERCISE
+			configObj, err := NewConfigFromCustomEnv(os.ReadFile, getEnv)
+			logText, _ := io.ReadAll(logOutput)
+
+			// ASSERT
+			tc.assert(t, err, configObj, string(logText))
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing.yaml")
+		getEnv := func(key string) string {
+			return map[string]string{configFileVarName: path}[key]
+		}
+
+		configObj, err := NewConfigFromCustomEnv(os.ReadFile, getEnv)
+		assert.ErrorContains(t, err, "CAKC135 Failed to read configuration file "+path)
+		assert.Nil(t, configObj)
+	})
+}
@@ -6,7 +6,7 @@ import (
 
 // Configuration defines interface for Configuration of an authentication flow
 type Configuration interface {
-	LoadConfig(settings map[string]string)
+	LoadConfig(settings map[string]string) error
 	GetEnvVariables() []string
 	GetRequiredVariables() []string
 	GetDefaultValues() map[string]string
@@ -24,37 +24,115 @@ func NewConfigFromEnv() (Configuration, error) {
 	return ConfigFromEnv(os.ReadFile)
 }
 
+// NewConfigFromEnvAndFile returns a new authenticator configuration object
+// read from the environment and from the given YAML or JSON configuration
+// file. Environment variables take precedence over the file. An empty path
+// falls back to CONJUR_CONFIG_FILE.
+func NewConfigFromEnvAndFile(configFile string) (Configuration, error) {
+	return newConfig(os.ReadFile, os.Getenv, configFile, os.Environ())
+}
+
 // ConfigFromEnv returns a new authenticator configuration object
 func ConfigFromEnv(readFileFunc common.ReadFileFunc) (Configuration, error) {
-	return NewConfigFromCustomEnv(readFileFunc, os.Getenv)
+	return newConfig(readFileFunc, os.Getenv, "", os.Environ())
 }
 
 func NewConfigFromCustomEnv(readFileFunc common.ReadFileFunc, customEnv func(key string) string) (Configuration, error) {
+	return newConfig(readFileFunc, customEnv, "", nil)
+}
+
+// newConfig reads the configuration from customEnv and the configuration
+// file. The CONJUR_*, JWT_* and MY_POD_* variables in environ are checked for
+// unknown settings.
+func newConfig(
+	readFileFunc common.ReadFileFunc,
+	customEnv func(key string) string,
+	configFile string,
+	environ []string,
+) (Configuration, error) {
 	log.Debug(log.CAKC068)
-	logLevel := getConfiguredLogLevel(customEnv)
+	if configFile == "" {
+		configFile = customEnv(configFileVarName)
+	}
+	getters, fileSettings, err := settingGetters(readFileFunc, customEnv, configFile)
+	if err != nil {
+		return nil, err
+	}
+	getEnv := getConfigVariable(getters.funcs()...)
+
+	logLevel := getConfiguredLogLevel(getEnv)
 	log.SetLogLevel(logLevel)
-	authnUrl := customEnv(authnURLVarName)
+	authnUrl := getEnv(authnURLVarName)
 	conf, err := getConfiguration(authnUrl)
 	if err != nil {
 		return nil, err
 	}
-	envSettings := GatherSettings(conf, customEnv)
+	strict := parseBool(strictSettingsVarName, getEnv(strictSettingsVarName))
+	unknownErrs := checkUnknownSettings(environ, fileSettings, configFile, strict)
+	envSettings, sources := GatherSettingsWithSources(conf, getters...)
+	logEffectiveSettings(envSettings, sources)
 
-	errLogs := envSettings.validate(conf, readFileFunc)
+	errLogs := append(envSettings.validate(conf, readFileFunc), unknownErrs...)
 	if len(errLogs) > 0 {
 		logErrors(errLogs)
 		return nil, errors.New(log.CAKC061)
 	}
 	log.Debug(log.CAKC074)
 
-	conf.LoadConfig(envSettings)
+	// The CA is only bootstrapped once the settings are known to be valid
+	if err := envSettings.bootstrapCA(readFileFunc); err != nil {
+		return nil, err
+	}
+
+	if err := conf.LoadConfig(envSettings); err != nil {
+		return nil, log.RecordedError(log.CAKC143, err)
+	}
 	return conf, nil
 }
 
+// settingGetters returns the getters of the settings given to the client, in
+// order of precedence: the pod annotations first, then the environment, then
+// the configuration file. It also returns the settings read from the file.
+func settingGetters(
+	readFileFunc common.ReadFileFunc,
+	customEnv func(key string) string,
+	configFile string,
+) (SettingGetters, AuthnSettings, error) {
+	getters := SettingGetters{{Source: SourceEnv, Get: customEnv}}
+	var fileSettings AuthnSettings
+	if configFile != "" {
+		var err error
+		fileSettings, err = readConfigFile(configFile, readFileFunc)
+		if err != nil {
+			return nil, nil, err
+		}
+		getters = append(getters, SettingGetter{Source: SourceFile, Get: fileSettings.get})
+	}
+	if annotationsFile := getConfigVariable(getters.funcs()...)(annotationsFileVarName); annotationsFile != "" {
+		getAnnotation, err := NewAnnotationsGetter(annotationsFile, readFileFunc)
+		if err != nil {
+			return nil, nil, err
+		}
+		getters = append(SettingGetters{{Source: SourceAnnotation, Get: getAnnotation}}, getters...)
+	}
+	return getters, fileSettings, nil
+}
+
 // GatherSettings retrieves authenticator client configuration settings from a slice
 // of arbitrary `func(key string) string` functions. Values received from 'Getter' functions
 // are prioritized in the order that the functions are provided.
 func GatherSettings(conf Configuration, getters ...func(key string) string) AuthnSettings {
+	named := make(SettingGetters, len(getters))
+	for i, getter := range getters {
+		named[i] = SettingGetter{Source: SourceCustom, Get: getter}
+	}
+	settings, _ := GatherSettingsWithSources(conf, named...)
+	return settings
+}
+
+// GatherSettingsWithSources retrieves authenticator client configuration
+// settings like GatherSettings, and records the source of each of them
+func GatherSettingsWithSources(conf Configuration, getters ...SettingGetter) (AuthnSettings, SettingSources) {
 	log.Debug(log.CAKC071)
 	defaultVariables := conf.GetDefaultValues()
 
@@ -62,17 +140,27 @@ func GatherSettings(conf Configuration, getters ...func(key string) string) Auth
 		return defaultVariables[key]
 	}
 
-	getters = append(getters, getDefault)
+	all := append(SettingGetters(getters), SettingGetter{Source: SourceDefault, Get: getDefault})
 	settings := make(AuthnSettings)
-	getEnv := getConfigVariable(getters...)
+	sources := make(SettingSources)
+	getEnv := getConfigVariable(all.funcs()...)
 
 	for _, key := range conf.GetEnvVariables() {
-		value := getEnv(key)
-		settings[key] = value
+		settings[key], sources[key] = all.lookup(key)
+	}
+
+	// Expand the pod metadata placeholders of a templated login, so that a
+	// single host template can be shared by many workloads
+	if login, ok := settings["CONJUR_AUTHN_LOGIN"]; ok {
+		expanded := common.ExpandLogin(login, getEnv)
+		if expanded != login {
+			log.Info(log.CAKC134, login, expanded)
+			settings["CONJUR_AUTHN_LOGIN"] = expanded
+		}
 	}
 
 	log.Debug(log.CAKC072)
-	return settings
+	return settings, sources
 }
 
 func getConfiguration(url string) (Configuration, error) {
@@ -92,6 +180,16 @@ func getConfiguration(url string) (Configuration, error) {
 // client configuration. Returns a list of Error logs.
 func (settings AuthnSettings) validate(conf Configuration, readFileFunc common.ReadFileFunc) []error {
 	log.Debug(log.CAKC073)
+	return settings.validateWith(conf, readFileFunc, common.ValidateSetting)
+}
+
+// validateWith validates the settings like validate, checking the value of
+// each setting with validateSetting
+func (settings AuthnSettings) validateWith(
+	conf Configuration,
+	readFileFunc common.ReadFileFunc,
+	validateSetting func(key, value string) error,
+) []error {
 	errorLogs := []error{}
 
 	// ensure required values exist
@@ -103,23 +201,128 @@ func (settings AuthnSettings) validate(conf Configuration, readFileFunc common.R
 
 	// ensure provided values are of the correct type
 	for _, key := range conf.GetEnvVariables() {
-		err := common.ValidateSetting(key, settings[key])
+		// JWT_TOKEN_PATH is only read when the JWT comes from a file, and
+		// its default does not exist outside of Kubernetes
+		if key == "JWT_TOKEN_PATH" && !settings.readsJWTFromFile() {
+			continue
+		}
+
+		err := validateSetting(key, settings[key])
 		if err != nil {
 			errorLogs = append(errorLogs, err)
 		}
 	}
 
-	// ensure that the certificate settings are valid
+	// pinning the public key of Conjur requires pins
+	for _, key := range []string{"CONJUR_SSL_PIN_ONLY", "CONJUR_SSL_BOOTSTRAP"} {
+		if settings.enabled(key) && settings["CONJUR_SSL_PINS"] == "" {
+			errorLogs = append(errorLogs, fmt.Errorf(log.CAKC062, "CONJUR_SSL_PINS"))
+		}
+	}
+
+	if proxyCAFile := settings["CONJUR_PROXY_CA_FILE"]; proxyCAFile != "" {
+		if _, err := common.ReadCABundle(proxyCAFile, readFileFunc); err != nil {
+			errorLogs = append(errorLogs, fmt.Errorf(log.CAKC152, proxyCAFile, err))
+		}
+	}
+
+	// ensure that the certificate settings are valid. With pinning only, no
+	// CA certificate is needed, and a bootstrapped CA is read once written.
+	if settings.pinsOnly() || settings.bootstrapsCA() {
+		return errorLogs
+	}
+	if err := settings.readSSLCert(readFileFunc); err != nil {
+		errorLogs = append(errorLogs, err)
+	}
+
+	return errorLogs
+}
+
+// readSSLCert sets CONJUR_SSL_CERTIFICATE to the certificates of
+// CONJUR_CERT_FILE, unless it is given
+func (settings AuthnSettings) readSSLCert(readFileFunc common.ReadFileFunc) error {
 	cert, err := common.ReadSSLCert(settings, readFileFunc)
 	if err != nil {
-		errorLogs = append(errorLogs, err)
+		return err
+	}
+	if settings["CONJUR_SSL_CERTIFICATE"] == "" {
+		settings["CONJUR_SSL_CERTIFICATE"] = string(cert)
 	} else {
-		if settings["CONJUR_SSL_CERTIFICATE"] == "" {
-			settings["CONJUR_SSL_CERTIFICATE"] = string(cert)
-		}
+		// CONJUR_SSL_CERTIFICATE takes precedence, so the bundle file is
+		// neither used nor reloaded
+		settings["CONJUR_CERT_FILE"] = ""
 	}
+	return nil
+}
 
-	return errorLogs
+// enabled returns true if the boolean setting is true, parsed like when the
+// settings are loaded, so that e.g. 1 and TRUE are true too
+func (settings AuthnSettings) enabled(key string) bool {
+	return parseBool(key, settings[key])
+}
+
+// parseBool parses the value of a boolean setting with the settings registry.
+// Invalid values are false, and are reported by the validation.
+func parseBool(key, value string) bool {
+	setting, _ := common.LookupSetting(key)
+	parsed, err := setting.Parse(value)
+	enabled, _ := parsed.(bool)
+	return err == nil && enabled
+}
+
+// pinsOnly returns true if the Conjur server is verified by its public key
+// only, without CA certificates
+func (settings AuthnSettings) pinsOnly() bool {
+	return settings.enabled("CONJUR_SSL_PIN_ONLY") && settings["CONJUR_SSL_PINS"] != "" &&
+		settings["CONJUR_SSL_CERTIFICATE"] == "" && settings["CONJUR_CERT_FILE"] == ""
+}
+
+// bootstrapsCA returns true if the Conjur CA certificate is to be bootstrapped:
+// CONJUR_SSL_BOOTSTRAP is set with pins and CONJUR_CERT_FILE does not exist yet
+func (settings AuthnSettings) bootstrapsCA() bool {
+	certFile := settings["CONJUR_CERT_FILE"]
+	if !settings.enabled("CONJUR_SSL_BOOTSTRAP") || settings["CONJUR_SSL_PINS"] == "" ||
+		settings["CONJUR_SSL_CERTIFICATE"] != "" || certFile == "" {
+		return false
+	}
+	_, err := os.Stat(certFile)
+	return err != nil
+}
+
+// bootstrapCA writes the Conjur CA certificate matching the pins to
+// CONJUR_CERT_FILE and reads it, when the CA is to be bootstrapped. The
+// server is reached through the transport of the other Conjur requests.
+func (settings AuthnSettings) bootstrapCA(readFileFunc common.ReadFileFunc) error {
+	if !settings.bootstrapsCA() {
+		return nil
+	}
+
+	var transport common.TransportConfig
+	if err := common.LoadSettings(&transport, settings); err != nil {
+		return log.RecordedError(log.CAKC143, err)
+	}
+	pins := common.ParseList(settings["CONJUR_SSL_PINS"])
+	err := common.BootstrapCA(settings[authnURLVarName], transport, pins, settings["CONJUR_CERT_FILE"])
+	if err != nil {
+		return err
+	}
+	if err := settings.readSSLCert(readFileFunc); err != nil {
+		return log.RecordedError(log.CAKC143, err)
+	}
+	return nil
+}
+
+func (settings AuthnSettings) readsJWTFromFile() bool {
+	sources := jwtAuthenticator.ParseTokenSources(settings["JWT_TOKEN_SOURCE"])
+	if len(sources) == 0 {
+		return true
+	}
+	for _, source := range sources {
+		if source == jwtAuthenticator.TokenSourceFile {
+			return true
+		}
+	}
+	return false
 }
 
 func logErrors(errLogs []error) {
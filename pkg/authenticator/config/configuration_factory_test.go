@@ -2,12 +2,20 @@ package config
 
 import (
 	"bytes"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	jwtAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/jwt"
 	logger "github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 	"github.com/stretchr/testify/assert"
 )
@@ -89,6 +97,17 @@ func TestValidate(t *testing.T) {
 			},
 			assert: assertErrorInList(fmt.Errorf(logger.CAKC032, "bad-username")),
 		},
+		{
+			description: "error raised for unresolved login placeholder",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":   "authn-k8s",
+				"CONJUR_ACCOUNT":     "testAccount",
+				"CONJUR_AUTHN_LOGIN": "host/apps/${deployment}",
+				"MY_POD_NAME":        "testPodName",
+				"MY_POD_NAMESPACE":   "testNameSpace",
+			},
+			assert: assertErrorInList(fmt.Errorf(logger.CAKC133, "${deployment}")),
+		},
 		{
 			description: "error raised for invalid retry count limit",
 			settings: AuthnSettings{
@@ -114,6 +133,30 @@ func TestValidate(t *testing.T) {
 			},
 			assert: assertErrorInList(fmt.Errorf(logger.CAKC060, "CONJUR_TOKEN_TIMEOUT", "seventeen")),
 		},
+		{
+			description: "error raised for invalid certificate renewal fraction",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":                    "authn-k8s",
+				"CONJUR_ACCOUNT":                      "testAccount",
+				"CONJUR_AUTHN_LOGIN":                  "host",
+				"MY_POD_NAME":                         "testPodName",
+				"MY_POD_NAMESPACE":                    "testNameSpace",
+				"CONJUR_CLIENT_CERT_RENEWAL_FRACTION": "1.5",
+			},
+			assert: assertErrorInList(fmt.Errorf(logger.CAKC060, "CONJUR_CLIENT_CERT_RENEWAL_FRACTION", "1.5")),
+		},
+		{
+			description: "error raised for invalid pod IP",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":   "authn-k8s",
+				"CONJUR_ACCOUNT":     "testAccount",
+				"CONJUR_AUTHN_LOGIN": "host",
+				"MY_POD_NAME":        "testPodName",
+				"MY_POD_NAMESPACE":   "testNameSpace",
+				"MY_POD_IP":          "10.0.0.300",
+			},
+			assert: assertErrorInList(fmt.Errorf(logger.CAKC060, "MY_POD_IP", "10.0.0.300")),
+		},
 		{
 			description: "error raised for invalid certificate",
 			settings: AuthnSettings{
@@ -130,6 +173,101 @@ func TestValidate(t *testing.T) {
 			},
 			assert: assertErrorInList(errors.New(logger.CAKC007)),
 		},
+		{
+			description: "error raised for invalid CA bundle file",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":   "authn-k8s",
+				"CONJUR_ACCOUNT":     "testAccount",
+				"CONJUR_AUTHN_LOGIN": "host",
+				"MY_POD_NAME":        "testPodName",
+				"MY_POD_NAMESPACE":   "testNameSpace",
+				"CONJUR_CERT_FILE":   "conjur.pem",
+			},
+			assert: assertErrorInList(fmt.Errorf(logger.CAKC145, "conjur.pem", "no PEM encoded certificate")),
+		},
+		{
+			description: "no CA certificate needed when pinning only",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":    "authn-k8s",
+				"CONJUR_ACCOUNT":      "testAccount",
+				"CONJUR_AUTHN_LOGIN":  "host",
+				"MY_POD_NAME":         "testPodName",
+				"MY_POD_NAMESPACE":    "testNameSpace",
+				"CONJUR_SSL_PINS":     "sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+				"CONJUR_SSL_PIN_ONLY": "true",
+			},
+			assert: assertEmptyErrorList(),
+		},
+		{
+			description: "pinning only is enabled by any true value",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":    "authn-k8s",
+				"CONJUR_ACCOUNT":      "testAccount",
+				"CONJUR_AUTHN_LOGIN":  "host",
+				"MY_POD_NAME":         "testPodName",
+				"MY_POD_NAMESPACE":    "testNameSpace",
+				"CONJUR_SSL_PINS":     "sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+				"CONJUR_SSL_PIN_ONLY": "TRUE",
+			},
+			assert: assertEmptyErrorList(),
+		},
+		{
+			description: "error raised for pinning only without pins",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":       "authn-k8s",
+				"CONJUR_ACCOUNT":         "testAccount",
+				"CONJUR_AUTHN_LOGIN":     "host",
+				"MY_POD_NAME":            "testPodName",
+				"MY_POD_NAMESPACE":       "testNameSpace",
+				"CONJUR_SSL_CERTIFICATE": "samplecertificate",
+				"CONJUR_SSL_PIN_ONLY":    "1",
+			},
+			assert: assertErrorInList(fmt.Errorf(logger.CAKC062, "CONJUR_SSL_PINS")),
+		},
+		{
+			description: "error raised for invalid proxy CA file",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":       "authn-k8s",
+				"CONJUR_ACCOUNT":         "testAccount",
+				"CONJUR_AUTHN_LOGIN":     "host",
+				"MY_POD_NAME":            "testPodName",
+				"MY_POD_NAMESPACE":       "testNameSpace",
+				"CONJUR_SSL_CERTIFICATE": "samplecertificate",
+				"CONJUR_PROXY_URL":       "https://proxy:3128",
+				"CONJUR_PROXY_CA_FILE":   "proxy.pem",
+			},
+			assert: assertErrorInList(fmt.Errorf(
+				logger.CAKC152,
+				"proxy.pem",
+				fmt.Errorf(logger.CAKC145, "proxy.pem", "no PEM encoded certificate"),
+			)),
+		},
+		{
+			description: "error raised for bootstrap without pins",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":       "authn-k8s",
+				"CONJUR_ACCOUNT":         "testAccount",
+				"CONJUR_AUTHN_LOGIN":     "host",
+				"MY_POD_NAME":            "testPodName",
+				"MY_POD_NAMESPACE":       "testNameSpace",
+				"CONJUR_SSL_CERTIFICATE": "samplecertificate",
+				"CONJUR_SSL_BOOTSTRAP":   "true",
+			},
+			assert: assertErrorInList(fmt.Errorf(logger.CAKC062, "CONJUR_SSL_PINS")),
+		},
+		{
+			description: "error raised for bootstrap with 1 without pins",
+			settings: AuthnSettings{
+				"CONJUR_AUTHN_URL":       "authn-k8s",
+				"CONJUR_ACCOUNT":         "testAccount",
+				"CONJUR_AUTHN_LOGIN":     "host",
+				"MY_POD_NAME":            "testPodName",
+				"MY_POD_NAMESPACE":       "testNameSpace",
+				"CONJUR_SSL_CERTIFICATE": "samplecertificate",
+				"CONJUR_SSL_BOOTSTRAP":   "1",
+			},
+			assert: assertErrorInList(fmt.Errorf(logger.CAKC062, "CONJUR_SSL_PINS")),
+		},
 	}
 
 	for _, tc := range TestCases {
@@ -224,6 +362,32 @@ func TestNewConfigFromEnv(t *testing.T) {
 				assert.Contains(t, logOutput, "CAKC070 Chosen \"authn-jwt\" configuration")
 			},
 		},
+		{
+			description: "templated login expanded from pod metadata",
+			envVars: mergeRequiredVars(
+				map[string]string{
+					"CONJUR_AUTHN_LOGIN":     "host/apps/${namespace}/${service_account}",
+					"MY_POD_NAMESPACE":       "testNameSpace",
+					"MY_POD_SERVICE_ACCOUNT": "testServiceAccount",
+				}),
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.NoError(t, err)
+				assert.Contains(t, logOutput, "CAKC134 Expanded CONJUR_AUTHN_LOGIN host/apps/${namespace}/${service_account} "+
+					"to host/apps/testNameSpace/testServiceAccount")
+			},
+		},
+		{
+			description: "templated login with unresolved placeholder",
+			envVars: mergeRequiredVars(
+				map[string]string{
+					"CONJUR_AUTHN_LOGIN":   "host/apps/${deployment}",
+					"CONJUR_POD_INFO_PATH": "/nonexistent",
+				}),
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.Error(t, err)
+				assert.Contains(t, logOutput, "CAKC133 Placeholder ${deployment} in CONJUR_AUTHN_LOGIN could not be resolved")
+			},
+		},
 	}
 
 	for _, tc := range TestCases {
@@ -292,3 +456,58 @@ func mergeRequiredVars(newVars map[string]string) map[string]string {
 	}
 	return requiredVars
 }
+
+func TestBootstrapCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	for _, bootstrap := range []string{"true", "1", "TRUE"} {
+		t.Run("CONJUR_SSL_BOOTSTRAP="+bootstrap, func(t *testing.T) {
+			certFile := filepath.Join(t.TempDir(), "conjur.pem")
+			env := AuthnSettings{
+				"CONJUR_AUTHN_URL":     server.URL + "/authn-jwt/my-authenticator-id",
+				"CONJUR_ACCOUNT":       "testAccount",
+				"CONJUR_CERT_FILE":     certFile,
+				"CONJUR_SSL_BOOTSTRAP": bootstrap,
+				"CONJUR_SSL_PINS":      common.SPKIFingerprint(server.Certificate()),
+				"JWT_TOKEN_SOURCE":     "env",
+			}
+
+			conf, err := NewConfigFromCustomEnv(os.ReadFile, env.get)
+			if !assert.NoError(t, err) {
+				return
+			}
+			serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			assert.Equal(t, serverCA, conf.(*jwtAuthenticator.Config).Common.SSLCertificate)
+			assert.Equal(t, certFile, conf.(*jwtAuthenticator.Config).Common.CertFile)
+			assert.FileExists(t, certFile)
+		})
+	}
+}
+
+func TestBootstrapCAAfterValidation(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	certFile := filepath.Join(t.TempDir(), "conjur.pem")
+	env := AuthnSettings{
+		"CONJUR_AUTHN_URL":     server.URL + "/authn-jwt/my-authenticator-id",
+		"CONJUR_CERT_FILE":     certFile,
+		"CONJUR_SSL_BOOTSTRAP": "true",
+		"CONJUR_SSL_PINS":      common.SPKIFingerprint(server.Certificate()),
+		"JWT_TOKEN_SOURCE":     "env",
+	}
+
+	// CONJUR_ACCOUNT is missing
+	_, err := NewConfigFromCustomEnv(os.ReadFile, env.get)
+	assert.EqualError(t, err, logger.CAKC061)
+	assert.Equal(t, int32(0), connections.Load())
+	assert.NoFileExists(t, certFile)
+}
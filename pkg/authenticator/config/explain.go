@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// SettingSource identifies where the value of a setting came from
+type SettingSource string
+
+// Sources recorded by GatherSettingsWithSources
+const (
+	SourceAnnotation SettingSource = "annotation"
+	SourceEnv        SettingSource = "env"
+	SourceFile       SettingSource = "file"
+	SourceCustom     SettingSource = "custom"
+	SourceDefault    SettingSource = "default"
+	SourceUnset      SettingSource = "unset"
+)
+
+// Output formats of WriteEffectiveConfig
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// SettingGetter is a settings getter along with the source of its values
+type SettingGetter struct {
+	Source SettingSource
+	Get    func(key string) string
+}
+
+// SettingGetters are settings getters in order of precedence
+type SettingGetters []SettingGetter
+
+// SettingSources maps settings to the source of their value
+type SettingSources map[string]SettingSource
+
+// EffectiveSetting is a resolved setting, as reported by ExplainConfig
+type EffectiveSetting struct {
+	Name   string        `json:"name"`
+	Value  string        `json:"value"`
+	Source SettingSource `json:"source"`
+}
+
+func (getters SettingGetters) funcs() []func(key string) string {
+	funcs := make([]func(key string) string, len(getters))
+	for i, getter := range getters {
+		funcs[i] = getter.Get
+	}
+	return funcs
+}
+
+// lookup returns the first value set for the key, and its source
+func (getters SettingGetters) lookup(key string) (string, SettingSource) {
+	for _, getter := range getters {
+		if value := getter.Get(key); len(value) > 0 {
+			return value, getter.Source
+		}
+	}
+	return "", SourceUnset
+}
+
+// ExplainConfig returns the settings the client would use, read like
+// NewConfigFromEnvAndFile does, and where each of them came from. The settings
+// are not validated, so that a broken configuration can be explained too.
+func ExplainConfig(
+	readFileFunc common.ReadFileFunc,
+	customEnv func(key string) string,
+	configFile string,
+) ([]EffectiveSetting, error) {
+	if configFile == "" {
+		configFile = customEnv(configFileVarName)
+	}
+	getters, fileSettings, err := settingGetters(readFileFunc, customEnv, configFile)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := getConfiguration(getConfigVariable(getters.funcs()...)(authnURLVarName))
+	if err != nil {
+		return nil, err
+	}
+	checkUnknownSettings(nil, fileSettings, configFile, false)
+
+	settings, sources := GatherSettingsWithSources(conf, getters...)
+	return effectiveSettings(settings, sources), nil
+}
+
+// WriteEffectiveConfig writes the settings as a table or as JSON
+func WriteEffectiveConfig(w io.Writer, settings []EffectiveSetting, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(settings)
+	case FormatTable:
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "SETTING\tSOURCE\tVALUE")
+		for _, setting := range settings {
+			fmt.Fprintf(table, "%s\t%s\t%s\n", setting.Name, setting.Source, setting.Value)
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf(log.CAKC060, "format", format)
+	}
+}
+
+// effectiveSettings returns the settings sorted by name, with redacted values
+func effectiveSettings(settings AuthnSettings, sources SettingSources) []EffectiveSetting {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	effective := make([]EffectiveSetting, len(names))
+	for i, name := range names {
+		effective[i] = EffectiveSetting{
+			Name:   name,
+			Value:  redactSetting(name, settings[name]),
+			Source: sources[name],
+		}
+	}
+	return effective
+}
+
+// redactSetting replaces the value of secret-bearing settings with their
+// fingerprint
+func redactSetting(name, value string) string {
+	setting, _ := common.LookupSetting(name)
+	return setting.Redact(value)
+}
+
+// logEffectiveSettings logs the settings and their sources at debug level
+func logEffectiveSettings(settings AuthnSettings, sources SettingSources) {
+	log.Debug(log.CAKC140)
+	for _, setting := range effectiveSettings(settings, sources) {
+		log.Debug(log.CAKC141, setting.Name, setting.Value, setting.Source)
+	}
+}
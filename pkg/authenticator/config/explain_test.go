@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	jwtAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/jwt"
+)
+
+func findSetting(settings []EffectiveSetting, name string) EffectiveSetting {
+	for _, setting := range settings {
+		if setting.Name == name {
+			return setting
+		}
+	}
+	return EffectiveSetting{}
+}
+
+func TestExplainConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "conjur.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("CONJUR_ACCOUNT: fileAccount\nCONTAINER_MODE: sidecar\n"), 0644))
+
+	env := map[string]string{
+		"CONJUR_AUTHN_URL":       "https://conjur/authn-jwt/my-service",
+		"CONJUR_CONFIG_FILE":     configFile,
+		"CONJUR_SSL_CERTIFICATE": "not a certificate",
+		"CONTAINER_MODE":         "init",
+	}
+	getEnv := func(key string) string {
+		return env[key]
+	}
+
+	settings, err := ExplainConfig(os.ReadFile, getEnv, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, EffectiveSetting{"CONTAINER_MODE", "init", SourceEnv}, findSetting(settings, "CONTAINER_MODE"))
+	assert.Equal(t, EffectiveSetting{"CONJUR_ACCOUNT", "fileAccount", SourceFile}, findSetting(settings, "CONJUR_ACCOUNT"))
+	assert.Equal(
+		t,
+		EffectiveSetting{"JWT_CLOCK_SKEW", jwtAuthenticator.DefaultJWTClockSkew, SourceDefault},
+		findSetting(settings, "JWT_CLOCK_SKEW"),
+	)
+	assert.Equal(t, EffectiveSetting{"JWT_OIDC_SCOPE", "", SourceUnset}, findSetting(settings, "JWT_OIDC_SCOPE"))
+
+	// The certificate is redacted to a fingerprint
+	cert := findSetting(settings, "CONJUR_SSL_CERTIFICATE")
+	assert.Equal(t, SourceEnv, cert.Source)
+	assert.True(t, strings.HasPrefix(cert.Value, "sha256:"))
+	assert.NotContains(t, cert.Value, "not a certificate")
+
+	// Settings are sorted by name
+	for i := 1; i < len(settings); i++ {
+		assert.Less(t, settings[i-1].Name, settings[i].Name)
+	}
+}
+
+func TestGatherSettingsWithSources(t *testing.T) {
+	getters := SettingGetters{
+		{Source: SourceAnnotation, Get: func(key string) string {
+			return map[string]string{"CONTAINER_MODE": "init"}[key]
+		}},
+		{Source: SourceEnv, Get: func(key string) string {
+			return map[string]string{"CONTAINER_MODE": "sidecar", "CONJUR_ACCOUNT": "testAccount"}[key]
+		}},
+	}
+
+	settings, sources := GatherSettingsWithSources(&jwtAuthenticator.Config{}, getters...)
+
+	assert.Equal(t, "init", settings["CONTAINER_MODE"])
+	assert.Equal(t, SourceAnnotation, sources["CONTAINER_MODE"])
+	assert.Equal(t, SourceEnv, sources["CONJUR_ACCOUNT"])
+	assert.Equal(t, SourceDefault, sources["JWT_TOKEN_SOURCE"])
+	assert.Equal(t, SourceUnset, sources["CONJUR_AUTHN_LOGIN"])
+}
+
+func TestWriteEffectiveConfig(t *testing.T) {
+	settings := []EffectiveSetting{
+		{Name: "CONJUR_ACCOUNT", Value: "testAccount", Source: SourceEnv},
+		{Name: "CONJUR_AUTHN_LOGIN", Value: "", Source: SourceUnset},
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, WriteEffectiveConfig(&out, settings, FormatTable))
+		assert.Equal(
+			t,
+			"SETTING             SOURCE  VALUE\n"+
+				"CONJUR_ACCOUNT      env     testAccount\n"+
+				"CONJUR_AUTHN_LOGIN  unset   \n",
+			out.String(),
+		)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, WriteEffectiveConfig(&out, settings, FormatJSON))
+
+		var decoded []EffectiveSetting
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, settings, decoded)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		var out bytes.Buffer
+		err := WriteEffectiveConfig(&out, settings, "yaml")
+		assert.EqualError(t, err, "CAKC060 Setting format given invalid value yaml")
+	})
+}
+
+func TestRedactSetting(t *testing.T) {
+	assert.Equal(t, "testAccount", redactSetting("CONJUR_ACCOUNT", "testAccount"))
+	assert.Equal(t, "", redactSetting("CONJUR_SSL_CERTIFICATE", ""))
+	assert.Equal(
+		t,
+		"sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		redactSetting("CONJUR_SSL_CERTIFICATE", "hello"),
+	)
+}
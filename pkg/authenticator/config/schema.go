@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// jsonSchema is the subset of JSON schema draft-07 emitted by JSONSchema, the
+// draft used by the Helm charts' values.schema.json
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// durationPattern matches the values accepted by time.ParseDuration
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+// JSONSchema returns a JSON schema of the settings of the given authenticator,
+// or of every setting if authnType is empty. Settings are keyed by name, and
+// only required settings of the given authenticator are marked as required.
+func JSONSchema(authnType string) ([]byte, error) {
+	if authnType != "" && authnType != common.AuthnTypeK8s && authnType != common.AuthnTypeJWT {
+		return nil, fmt.Errorf(log.CAKC060, "authenticator", authnType)
+	}
+
+	additionalProperties := false
+	schema := jsonSchema{
+		Schema:               "http://json-schema.org/draft-07/schema",
+		Type:                 "object",
+		Properties:           map[string]*jsonSchema{},
+		AdditionalProperties: &additionalProperties,
+	}
+	for _, setting := range common.Settings() {
+		if authnType != "" && !setting.UsedBy(authnType) {
+			continue
+		}
+		schema.Properties[setting.Name] = settingSchema(setting)
+		if authnType != "" && setting.RequiredFor(authnType) {
+			schema.Required = append(schema.Required, setting.Name)
+		}
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func settingSchema(setting common.Setting) *jsonSchema {
+	schema := &jsonSchema{Type: "string", Description: setting.Description}
+	if setting.Default != "" {
+		schema.Default = setting.Default
+	}
+
+	switch setting.Type {
+	case common.SettingInt:
+		schema.Type = "integer"
+	case common.SettingBool:
+		schema.Type = "boolean"
+	case common.SettingFraction:
+		minimum, maximum := 0.0, 1.0
+		schema.Type = "number"
+		schema.Minimum = &minimum
+		schema.ExclusiveMaximum = &maximum
+	case common.SettingDuration:
+		schema.Pattern = durationPattern
+	case common.SettingURL:
+		schema.Format = "uri"
+	case common.SettingUsername:
+		schema.Pattern = "^host/"
+	}
+	if len(setting.Values) > 0 {
+		schema.Enum = setting.Values
+	}
+
+	// Typed defaults, so that they validate against the schema
+	if setting.Default != "" && schema.Type != "string" {
+		if parsed, err := setting.Parse(setting.Default); err == nil {
+			schema.Default = parsed
+		}
+	}
+	return schema
+}
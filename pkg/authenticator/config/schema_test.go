@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	jwtAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/jwt"
+	k8sAuthenticator "github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/k8s"
+)
+
+func TestJSONSchema(t *testing.T) {
+	t.Run("authn-k8s", func(t *testing.T) {
+		raw, err := JSONSchema(common.AuthnTypeK8s)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var schema jsonSchema
+		assert.NoError(t, json.Unmarshal(raw, &schema))
+		assert.Equal(t, "http://json-schema.org/draft-07/schema", schema.Schema)
+		assert.Equal(t, common.RequiredSettingNames(common.AuthnTypeK8s), schema.Required)
+		assert.Len(t, schema.Properties, len(common.SettingNames(common.AuthnTypeK8s)))
+		assert.NotContains(t, schema.Properties, "JWT_TOKEN_SOURCE")
+
+		retryCountLimit := schema.Properties["CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT"]
+		assert.Equal(t, "integer", retryCountLimit.Type)
+		assert.Equal(t, float64(10), retryCountLimit.Default)
+
+		renewalFraction := schema.Properties["CONJUR_CLIENT_CERT_RENEWAL_FRACTION"]
+		assert.Equal(t, "number", renewalFraction.Type)
+		assert.Equal(t, 0.0, renewalFraction.Default)
+		assert.Equal(t, 1.0, *renewalFraction.ExclusiveMaximum)
+
+		timeout := schema.Properties["CONJUR_TOKEN_TIMEOUT"]
+		assert.Equal(t, "string", timeout.Type)
+		assert.Equal(t, "6m0s", timeout.Default)
+		assert.Regexp(t, timeout.Pattern, "6m0s")
+		assert.Regexp(t, timeout.Pattern, "1h30m")
+		assert.NotRegexp(t, timeout.Pattern, "six minutes")
+
+		tlsMinVersion := schema.Properties["CONJUR_TLS_MIN_VERSION"]
+		assert.Equal(t, "1.2", tlsMinVersion.Default)
+		assert.Equal(t, []string{"1.2", "1.3"}, tlsMinVersion.Enum)
+
+		keyProvider := schema.Properties["CONJUR_CLIENT_KEY_PROVIDER"]
+		assert.Equal(t, []string{"memory", "pkcs11"}, keyProvider.Enum)
+		assert.Contains(t, schema.Properties["CONJUR_CLIENT_KEY_ALGORITHM"].Enum, "ecdsa-p256")
+	})
+
+	t.Run("all settings", func(t *testing.T) {
+		raw, err := JSONSchema("")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var schema jsonSchema
+		assert.NoError(t, json.Unmarshal(raw, &schema))
+		assert.Empty(t, schema.Required)
+		assert.Len(t, schema.Properties, len(common.Settings()))
+	})
+
+	t.Run("unknown authenticator", func(t *testing.T) {
+		_, err := JSONSchema("authn-iam")
+		assert.EqualError(t, err, "CAKC060 Setting authenticator given invalid value authn-iam")
+	})
+}
+
+// TestLoadConfigFromRegistry checks that every setting tag of the
+// configurations is registered for their authenticator
+func TestLoadConfigFromRegistry(t *testing.T) {
+	for _, conf := range []Configuration{&k8sAuthenticator.Config{}, &jwtAuthenticator.Config{}} {
+		settings := map[string]string{}
+		for _, name := range conf.GetEnvVariables() {
+			setting, _ := common.LookupSetting(name)
+			settings[name] = setting.Default
+		}
+		settings["CONJUR_AUTHN_LOGIN"] = "host/apps/test-app"
+
+		assert.NoError(t, conf.LoadConfig(settings))
+		assert.Equal(t, 6*60, int(conf.GetTokenTimeout().Seconds()))
+	}
+}
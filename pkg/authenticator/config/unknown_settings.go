@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// strictSettingsVarName is the setting that turns unknown settings into
+// validation errors
+const strictSettingsVarName string = "CONJUR_STRICT_SETTINGS"
+
+// settingPrefixes are the prefixes of the environment variables checked for
+// unknown settings
+var settingPrefixes = []string{"CONJUR_", "JWT_", "MY_POD_"}
+
+// otherToolSettings are used by other Conjur clients, and are commonly set on
+// every container of a pod, e.g. from the conjur-connect ConfigMap
+var otherToolSettings = []string{
+	"CONJUR_APPLIANCE_URL",
+	"CONJUR_AUTHN_API_KEY",
+	"CONJUR_AUTHN_TOKEN",
+	"CONJUR_SSL_CERTIFICATE_BASE64",
+	"CONJUR_VERSION",
+}
+
+// kubernetesServiceVariable matches the variables Kubernetes sets for the
+// services in the pod namespace, e.g. CONJUR_SERVICE_HOST for a "conjur" service
+var kubernetesServiceVariable = regexp.MustCompile(
+	`_SERVICE_HOST$|_SERVICE_PORT(_[A-Z0-9_]+)?$|_PORT(_[0-9]+_(TCP|UDP|SCTP)(_PROTO|_PORT|_ADDR)?)?$`,
+)
+
+// knownSettings returns the settings used by any registered authenticator,
+// and by other Conjur clients, sorted by name
+func knownSettings() []string {
+	names := append([]string{}, otherToolSettings...)
+	for _, setting := range common.Settings() {
+		names = append(names, setting.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unknownEnvSettings returns the names of the CONJUR_*, JWT_* and MY_POD_*
+// variables in the environment that no authenticator uses
+func unknownEnvSettings(environ []string) []string {
+	names := []string{}
+	for _, variable := range environ {
+		name, _, _ := strings.Cut(variable, "=")
+		for _, prefix := range settingPrefixes {
+			if strings.HasPrefix(name, prefix) && !kubernetesServiceVariable.MatchString(name) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return unknownSettings(names)
+}
+
+// unknownSettings returns the given names that no authenticator uses, sorted
+func unknownSettings(names []string) []string {
+	known := map[string]bool{}
+	for _, name := range knownSettings() {
+		known[name] = true
+	}
+
+	unknown := []string{}
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// checkUnknownSettings reports the unknown settings in the environment and in
+// the configuration file. They are logged as warnings, or returned as errors
+// in strict mode.
+func checkUnknownSettings(environ []string, fileSettings AuthnSettings, configFile string, strict bool) []error {
+	fileNames := make([]string, 0, len(fileSettings))
+	for name := range fileSettings {
+		fileNames = append(fileNames, name)
+	}
+
+	errs := []error{}
+	report := func(names []string, location string) {
+		for _, name := range names {
+			err := unknownSettingError(name, location)
+			if strict {
+				errs = append(errs, err)
+			} else {
+				log.Warn(err.Error())
+			}
+		}
+	}
+	report(unknownEnvSettings(environ), "the environment")
+	report(unknownSettings(fileNames), "configuration file "+configFile)
+
+	return errs
+}
+
+func unknownSettingError(name, location string) error {
+	if suggestion := suggestSetting(name); suggestion != "" {
+		return fmt.Errorf(log.CAKC142, name, location, suggestion)
+	}
+	return fmt.Errorf(log.CAKC137, name, location)
+}
+
+// suggestSetting returns the known setting closest to a misspelled one, or an
+// empty string if none is close enough
+func suggestSetting(name string) string {
+	maxDistance := 3
+	if len(name) < 12 {
+		maxDistance = 2
+	}
+
+	suggestion := ""
+	for _, known := range knownSettings() {
+		if distance := editDistance(name, known); distance <= maxDistance {
+			suggestion, maxDistance = known, distance-1
+		}
+	}
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
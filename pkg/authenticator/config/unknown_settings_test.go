@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	logger "github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownEnvSettings(t *testing.T) {
+	environ := []string{
+		"CONJUR_AUTHN_LOGN=host/test-app",
+		"CONJUR_ACCOUNT=testAccount",
+		"CONJUR_APPLIANCE_URL=https://conjur",
+		"CONJUR_SERVICE_HOST=10.0.0.1",
+		"CONJUR_SERVICE_PORT_HTTPS=443",
+		"CONJUR_PORT=tcp://10.0.0.1:443",
+		"CONJUR_PORT_443_TCP_ADDR=10.0.0.1",
+		"JWT_TOKEN_PATHS=/var/run/secrets/tokens/jwt",
+		"MY_POD_NAMESPACE=testNameSpace",
+		"MY_POD_DEPLOYMENT=test-app",
+		"MY_POD_LABELS=app=test",
+		"HOME=/root",
+		"CONJUR_NEW_FEATURE=on",
+	}
+
+	assert.Equal(
+		t,
+		[]string{"CONJUR_AUTHN_LOGN", "CONJUR_NEW_FEATURE", "JWT_TOKEN_PATHS", "MY_POD_LABELS"},
+		unknownEnvSettings(environ),
+	)
+}
+
+func TestSuggestSetting(t *testing.T) {
+	TestCases := []struct {
+		name       string
+		suggestion string
+	}{
+		{name: "CONJUR_AUTHN_LOGN", suggestion: "CONJUR_AUTHN_LOGIN"},
+		{name: "CONJUR_ACOUNT", suggestion: "CONJUR_ACCOUNT"},
+		{name: "JWT_TOKEN_PATHS", suggestion: "JWT_TOKEN_PATH"},
+		{name: "CONJUR_CLIENT_CERT_RETRY_LIMIT", suggestion: ""},
+		{name: "CONJUR_NEW_FEATURE", suggestion: ""},
+		{name: "MY_POD_IPS", suggestion: "MY_POD_IP"},
+	}
+
+	for _, tc := range TestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.suggestion, suggestSetting(tc.name))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("CONJUR_ACCOUNT", "CONJUR_ACCOUNT"))
+	assert.Equal(t, 1, editDistance("CONJUR_AUTHN_LOGN", "CONJUR_AUTHN_LOGIN"))
+	assert.Equal(t, 2, editDistance("CONJUR_AUTHN_LGOIN", "CONJUR_AUTHN_LOGIN"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
+
+func TestStrictSettings(t *testing.T) {
+	env := map[string]string{
+		"CONJUR_AUTHN_URL":       "authn-jwt",
+		"CONJUR_ACCOUNT":         "testAccount",
+		"CONJUR_SSL_CERTIFICATE": "samplecertificate",
+		"JWT_TOKEN_PATH":         "/tmp/token",
+		"CONJUR_AUTHN_LOGN":      "host/test-app",
+	}
+	getEnv := func(key string) string {
+		return env[key]
+	}
+	environ := []string{}
+	for key, value := range env {
+		environ = append(environ, key+"="+value)
+	}
+	message := "CAKC142 Setting CONJUR_AUTHN_LOGN in the environment is not used by the authenticator client, " +
+		"did you mean CONJUR_AUTHN_LOGIN?"
+
+	TestCases := []struct {
+		description string
+		strict      string
+		assert      configAssertFunc
+	}{
+		{
+			description: "warning by default",
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.NoError(t, err)
+				assert.Contains(t, logOutput, "WARN")
+				assert.Contains(t, logOutput, message)
+			},
+		},
+		{
+			description: "error in strict mode",
+			strict:      "true",
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.EqualError(t, err, logger.CAKC061)
+				assert.Contains(t, logOutput, "ERROR")
+				assert.Contains(t, logOutput, message)
+			},
+		},
+		{
+			description: "error in strict mode enabled with 1",
+			strict:      "1",
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.EqualError(t, err, logger.CAKC061)
+				assert.Contains(t, logOutput, message)
+			},
+		},
+		{
+			description: "error in strict mode enabled with TRUE",
+			strict:      "TRUE",
+			assert: func(t *testing.T, err error, config Configuration, logOutput string) {
+				assert.EqualError(t, err, logger.CAKC061)
+				assert.Contains(t, logOutput, message)
+			},
+		},
+	}
+
+	for _, tc := range TestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			env[strictSettingsVarName] = tc.strict
+
+			// Intercept logger output
+			logOutput := io.ReadWriter(&bytes.Buffer{})
+			logger.ErrorLogger.SetOutput(logOutput)
+			logger.InfoLogger.SetOutput(logOutput)
+			defer logger.ErrorLogger.SetOutput(os.Stderr)
+			defer logger.InfoLogger.SetOutput(os.Stdout)
+
+			configObj, err := newConfig(os.ReadFile, getEnv, "", environ)
+			logText, _ := io.ReadAll(logOutput)
+
+			tc.assert(t, err, configObj, string(logText))
+		})
+	}
+}
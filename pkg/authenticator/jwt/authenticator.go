@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/access_token"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
@@ -23,6 +25,7 @@ type Authenticator struct {
 	client      *http.Client
 	privateKey  *rsa.PrivateKey
 	accessToken access_token.AccessToken
+	source      tokenSource
 	Config      *Config
 }
 
@@ -33,7 +36,16 @@ func NewWithAccessToken(config Config, accessToken access_token.AccessToken) (*A
 		return nil, log.RecordedError(log.CAKC030, err)
 	}
 
-	client, err := common.NewHTTPSClient(config.Common.SSLCertificate, nil, nil)
+	caBundle, err := config.Common.CABundle()
+	if err != nil {
+		return nil, err
+	}
+	client, err := common.NewHTTPSClientWithCABundle(caBundle, config.Common.Transport, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := newTokenSource(config)
 	if err != nil {
 		return nil, err
 	}
@@ -42,6 +54,7 @@ func NewWithAccessToken(config Config, accessToken access_token.AccessToken) (*A
 		client:      client,
 		privateKey:  signingKey,
 		accessToken: accessToken,
+		source:      source,
 		Config:      &config,
 	}, nil
 }
@@ -51,6 +64,12 @@ func (auth *Authenticator) GetAccessToken() access_token.AccessToken {
 	return auth.accessToken
 }
 
+// Stop closes the idle connections to Conjur. The authenticator must not be
+// used afterwards.
+func (auth *Authenticator) Stop() {
+	auth.client.CloseIdleConnections()
+}
+
 // Authenticate sends Conjur an authenticate request and writes the response
 // to the token file (after decrypting it if needed). It also manages state of
 // certificates.
@@ -59,12 +78,17 @@ func (auth *Authenticator) Authenticate() error {
 	return auth.AuthenticateWithContext(context.TODO())
 }
 
+// AuthenticateWithContext is Authenticate with tracing. The request of each
+// attempt carries a new request ID, which tags its log messages and its span.
 func (auth *Authenticator) AuthenticateWithContext(ctx context.Context) error {
-	log.Info(log.CAKC066)
+	logger := log.WithRequestID(common.NewRequestID())
+	logger.Info(log.CAKC066)
 
 	tr := trace.NewOtelTracer(otel.Tracer("conjur-authn-k8s-client"))
 	spanCtx, span := tr.Start(ctx, "Authenticate")
 	defer span.End()
+	span.SetAttributes(attribute.String(common.RequestIDAttribute, logger.RequestID()))
+	spanCtx = log.NewContext(spanCtx, logger)
 
 	authenticationResponse, err := auth.sendAuthenticationRequest(spanCtx, tr)
 	if err != nil {
@@ -80,36 +104,45 @@ func (auth *Authenticator) AuthenticateWithContext(ctx context.Context) error {
 		return err
 	}
 
-	log.Info(log.CAKC035)
+	logger.Info(log.CAKC035)
 	return nil
 }
 
-// sendAuthenticationRequest reads the JWT token from the file system and sends
+// sendAuthenticationRequest obtains the JWT from the configured source and sends
 // an authentication request to the Conjur server. It also validates the response
 // code before returning its body
 func (auth *Authenticator) sendAuthenticationRequest(ctx context.Context, tracer trace.Tracer) ([]byte, error) {
 	var authenticatingIdentity string
 
-	_, span := tracer.Start(ctx, "Send authentication request")
+	spanCtx, span := tracer.Start(ctx, "Send authentication request")
 	defer span.End()
+	logger := log.FromContext(ctx)
 
-	jwtToken, err := loadJWTToken(auth.Config.JWTTokenFilePath)
+	jwtToken, err := auth.source.token(spanCtx)
+
+	if err != nil {
+		span.RecordErrorAndSetStatus(err)
+		return nil, err
+	}
 
+	// The identity is read from the JWT when no host is configured
+	err = validateClaims(logger, jwtToken, auth.Config.Validation, auth.Config.Common.Username == nil, time.Now())
 	if err != nil {
 		span.RecordErrorAndSetStatus(err)
 		return nil, err
 	}
 
-	log.Debug(log.CAKC078)
+	logger.Debug(log.CAKC078)
 	if auth.Config.Common.Username != nil {
 		authenticatingIdentity = auth.Config.Common.Username.FullUsername
-		log.Debug(log.CAKC079, authenticatingIdentity)
+		logger.Debug(log.CAKC079, authenticatingIdentity)
 	} else {
-		log.Debug(log.CAKC080)
+		logger.Debug(log.CAKC080)
 		authenticatingIdentity = ""
 	}
 
-	req, err := AuthenticateRequest(
+	req, err := AuthenticateRequestWithContext(
+		ctx,
 		auth.Config.Common.URL,
 		auth.Config.Common.Account,
 		authenticatingIdentity,
@@ -120,13 +153,15 @@ func (auth *Authenticator) sendAuthenticationRequest(ctx context.Context, tracer
 		span.RecordErrorAndSetStatus(err)
 		return nil, err
 	}
+	common.SetRequestID(req, logger.RequestID())
+	common.SetHeaders(req, auth.Config.Common.Transport.Headers)
 
-	log.Debug(log.CAKC069, AuthnType)
+	logger.Debug(log.CAKC069, AuthnType)
 	resp, err := auth.client.Do(req)
 
 	if err != nil {
 		span.RecordErrorAndSetStatus(err)
-		return nil, log.RecordedError(log.CAKC027, err)
+		return nil, logger.RecordedError(log.CAKC027, err)
 	}
 
 	err = utils.ValidateResponse(resp)
@@ -138,15 +173,15 @@ func (auth *Authenticator) sendAuthenticationRequest(ctx context.Context, tracer
 	return utils.ReadResponseBody(resp)
 }
 
-func loadJWTToken(path string) (string, error) {
-	log.Debug(log.CAKC076, path)
+func loadJWTToken(logger log.RequestLogger, path string) (string, error) {
+	logger.Debug(log.CAKC076, path)
 
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf(log.CAKC067, path)
 	}
 
-	log.Debug(log.CAKC077)
+	logger.Debug(log.CAKC077)
 
 	return string(data), nil
 }
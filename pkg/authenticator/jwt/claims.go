@@ -0,0 +1,43 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// decodeClaims returns the claims of a compact-serialized JWT. The signature
+// is not verified: Conjur does that. The client only inspects the claims to
+// manage the lifetime of the tokens it sends.
+func decodeClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf(log.CAKC090, "token is not made of three dot-separated parts")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf(log.CAKC090, err)
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf(log.CAKC090, err)
+	}
+
+	return claims, nil
+}
+
+// timeClaim returns the NumericDate claim with the given name, and whether
+// it was present and well-formed.
+func timeClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
@@ -0,0 +1,172 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+const (
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	clientAssertionLifetime = 5 * time.Minute
+)
+
+// loadSigningKey reads a PEM encoded RSA or ECDSA private key used to sign
+// private_key_jwt client assertions
+func loadSigningKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, log.RecordedError(log.CAKC085, path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, log.RecordedError(log.CAKC085, path, "no PEM block found")
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, log.RecordedError(log.CAKC085, path, err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		if _, _, err := ecdsaAlgorithm(k.Curve); err != nil {
+			return nil, log.RecordedError(log.CAKC085, path, err)
+		}
+		return k, nil
+	default:
+		return nil, log.RecordedError(log.CAKC085, path, "only RSA and ECDSA keys are supported")
+	}
+}
+
+// signClientAssertion creates the signed JWT with which the client
+// authenticates to the token endpoint, as described in RFC 7523
+func signClientAssertion(key crypto.Signer, keyID, clientID, audience string, now time.Time) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	claims := map[string]interface{}{
+		"iss": clientID,
+		"sub": clientID,
+		"aud": audience,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+	}
+
+	return signJWT(key, keyID, claims)
+}
+
+func signJWT(key crypto.Signer, keyID string, claims map[string]interface{}) (string, error) {
+	var alg string
+	var hash crypto.Hash
+	var err error
+
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		alg, hash = "RS256", crypto.SHA256
+	case *ecdsa.PublicKey:
+		alg, hash, err = ecdsaAlgorithm(k.Curve)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("only RSA and ECDSA keys are supported")
+	}
+
+	header := map[string]interface{}{"alg": alg, "typ": "JWT"}
+	if keyID != "" {
+		header["kid"] = keyID
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	var digest []byte
+	if hash == crypto.SHA384 {
+		sum := sha512.Sum384([]byte(signingInput))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(signingInput))
+		digest = sum[:]
+	}
+
+	signature, err := key.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return "", err
+	}
+
+	if pub, ok := key.Public().(*ecdsa.PublicKey); ok {
+		// JWS uses the fixed-size R || S encoding rather than ASN.1
+		signature, err = ecdsaRawSignature(signature, pub.Curve)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func ecdsaAlgorithm(curve elliptic.Curve) (string, crypto.Hash, error) {
+	switch curve {
+	case elliptic.P256():
+		return "ES256", crypto.SHA256, nil
+	case elliptic.P384():
+		return "ES384", crypto.SHA384, nil
+	default:
+		return "", 0, errors.New("only P-256 and P-384 ECDSA keys are supported")
+	}
+}
+
+func ecdsaRawSignature(der []byte, curve elliptic.Curve) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
+}
@@ -10,72 +10,107 @@ import (
 // for the authentication requests
 type Config struct {
 	Common           common.Config
-	JWTTokenFilePath string
+	JWTTokenFilePath string   `setting:"JWT_TOKEN_PATH"`
+	JWTTokenSources  []string `setting:"JWT_TOKEN_SOURCE"`
+	JWTTokenEnvVar   string   `setting:"JWT_TOKEN_ENV_VAR"`
+	JWTTokenCommand  string   `setting:"JWT_TOKEN_COMMAND"`
+	OIDC             OIDCConfig
+	TokenRequest     TokenRequestConfig
+	SPIFFE           SPIFFEConfig
+	Validation       ValidationConfig
 }
 
-// Default settings (this comment added to satisfy linter)
-const (
-	DefaultClientCertPath = "/etc/conjur/ssl/client.pem"
-	DefaultTokenFilePath  = "/run/conjur/access-token"
-
-	// DefaultTokenRefreshTimeout is the default time the system waits to reauthenticate on error
-	DefaultTokenRefreshTimeout = "6m0s"
-
-	// DefaultClientCertRetryCountLimit is the amount of times we wait after successful
-	// login for the client certificate file to exist, where each time we wait for a second.
-	DefaultClientCertRetryCountLimit = "10"
-
-	DefaultJWTTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
-
-	AuthnType = "authn-jwt"
-)
+// OIDCConfig defines the parameters of the OAuth2 client credentials grant
+// used by the "oidc" JWT source
+type OIDCConfig struct {
+	TokenURL         string `setting:"JWT_OIDC_TOKEN_URL"`
+	ClientID         string `setting:"JWT_OIDC_CLIENT_ID"`
+	ClientSecretPath string `setting:"JWT_OIDC_CLIENT_SECRET_PATH"`
+	PrivateKeyPath   string `setting:"JWT_OIDC_PRIVATE_KEY_PATH"`
+	KeyID            string `setting:"JWT_OIDC_KEY_ID"`
+	Scope            string `setting:"JWT_OIDC_SCOPE"`
+	Audience         string `setting:"JWT_OIDC_AUDIENCE"`
+}
 
-var requiredEnvVariables = []string{
-	"CONJUR_AUTHN_URL",
-	"CONJUR_ACCOUNT",
+// TokenRequestConfig defines the parameters used to mint a service account
+// token with the Kubernetes TokenRequest API in the "token-request" JWT source
+type TokenRequestConfig struct {
+	APIURL            string        `setting:"JWT_TOKEN_REQUEST_API_URL"`
+	Audience          string        `setting:"JWT_TOKEN_REQUEST_AUDIENCE"`
+	Expiration        time.Duration `setting:"JWT_TOKEN_REQUEST_EXPIRATION"`
+	Namespace         string        `setting:"MY_POD_NAMESPACE"`
+	ServiceAccount    string        `setting:"MY_POD_SERVICE_ACCOUNT"`
+	ServiceAccountDir string
 }
 
-var envVariables = []string{
-	"CONJUR_ACCOUNT",
-	"CONJUR_AUTHN_TOKEN_FILE",
-	"CONJUR_AUTHN_URL",
-	"CONJUR_CERT_FILE",
-	"CONJUR_SSL_CERTIFICATE",
-	"CONJUR_TOKEN_TIMEOUT",
-	"CONTAINER_MODE",
-	"DEBUG",
-	"LOG_LEVEL",
-	"JWT_TOKEN_PATH",
-	"CONJUR_AUTHN_LOGIN",
+// SPIFFEConfig defines the parameters used to fetch a JWT-SVID from the SPIFFE
+// Workload API in the "spiffe" JWT source
+type SPIFFEConfig struct {
+	EndpointSocket string `setting:"SPIFFE_ENDPOINT_SOCKET"`
+	Audience       string `setting:"JWT_SPIFFE_AUDIENCE"`
+	SPIFFEID       string `setting:"JWT_SPIFFE_ID"`
 }
 
-var defaultValues = map[string]string{
-	"CONJUR_CLIENT_CERT_PATH":              DefaultClientCertPath,
-	"CONJUR_AUTHN_TOKEN_FILE":              DefaultTokenFilePath,
-	"CONJUR_TOKEN_TIMEOUT":                 DefaultTokenRefreshTimeout,
-	"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT": DefaultClientCertRetryCountLimit,
-	"JWT_TOKEN_PATH":                       DefaultJWTTokenPath,
+// ValidationConfig defines the checks made on the claims of the JWT before it
+// is sent to Conjur
+type ValidationConfig struct {
+	ClockSkew     time.Duration `setting:"JWT_CLOCK_SKEW"`
+	Issuer        string        `setting:"JWT_EXPECTED_ISSUER"`
+	Audience      string        `setting:"JWT_EXPECTED_AUDIENCE"`
+	IdentityClaim string        `setting:"JWT_IDENTITY_CLAIM"`
 }
 
-func (config *Config) LoadConfig(settings map[string]string) {
+// Default settings, defined in the common settings registry
+const (
+	DefaultClientCertPath            = common.DefaultClientCertPath
+	DefaultTokenFilePath             = common.DefaultTokenFilePath
+	DefaultTokenRefreshTimeout       = common.DefaultTokenRefreshTimeout
+	DefaultClientCertRetryCountLimit = common.DefaultClientCertRetryCountLimit
+	DefaultJWTTokenPath              = common.DefaultJWTTokenPath
+
+	// TokenSourceFile reads the JWT from JWT_TOKEN_PATH
+	TokenSourceFile = common.DefaultJWTTokenSource
+	// TokenSourceEnv reads the JWT from the variable named by JWT_TOKEN_ENV_VAR
+	TokenSourceEnv = "env"
+	// TokenSourceExec reads the JWT from the output of JWT_TOKEN_COMMAND
+	TokenSourceExec = "exec"
+	// TokenSourceOIDC fetches the JWT from an OAuth2/OIDC token endpoint
+	TokenSourceOIDC = "oidc"
+	// TokenSourceTokenRequest mints a token with the Kubernetes TokenRequest API
+	TokenSourceTokenRequest = "token-request"
+	// TokenSourceSPIFFE fetches a JWT-SVID from the SPIFFE Workload API
+	TokenSourceSPIFFE = "spiffe"
+
+	DefaultKubernetesAPIURL       = common.DefaultKubernetesAPIURL
+	DefaultServiceAccountDir      = "/var/run/secrets/kubernetes.io/serviceaccount"
+	DefaultTokenRequestExpiration = common.DefaultTokenRequestExpiration
+	DefaultJWTClockSkew           = common.DefaultJWTClockSkew
+
+	AuthnType = common.AuthnTypeJWT
+)
+
+// LoadConfig sets the configuration from the settings of the authenticator
+func (config *Config) LoadConfig(settings map[string]string) error {
 	config.Common = common.Config{}
-	config.Common.LoadConfig(settings)
+	return common.LoadSettings(config, settings)
+}
 
-	if path, exists := settings["JWT_TOKEN_PATH"]; exists {
-		config.JWTTokenFilePath = path
-	}
+// ParseTokenSources splits a comma-separated JWT_TOKEN_SOURCE value into the
+// ordered list of source names
+func ParseTokenSources(value string) []string {
+	return common.ParseList(value)
 }
 
 func (config *Config) GetEnvVariables() []string {
-	return envVariables
+	return common.SettingNames(AuthnType)
 }
 
 func (config *Config) GetRequiredVariables() []string {
-	return requiredEnvVariables
+	return common.RequiredSettingNames(AuthnType)
 }
 
 func (config *Config) GetDefaultValues() map[string]string {
-	return defaultValues
+	return common.DefaultSettingValues(AuthnType)
 }
 
 func (config *Config) GetContainerMode() string {
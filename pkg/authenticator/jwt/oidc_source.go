@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/utils"
+)
+
+// oidcSource obtains a JWT from an OAuth2/OIDC token endpoint using the
+// client credentials grant. The client authenticates either with a secret
+// read from a file (client_secret_basic) or with a signed assertion
+// (private_key_jwt).
+type oidcSource struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// tokenResponse is the subset of an RFC 6749 token response used by the client
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func newOIDCSource(config OIDCConfig) (tokenSource, error) {
+	if config.TokenURL == "" {
+		return nil, log.RecordedError(log.CAKC009, "JWT_OIDC_TOKEN_URL")
+	}
+	if config.ClientID == "" {
+		return nil, log.RecordedError(log.CAKC009, "JWT_OIDC_CLIENT_ID")
+	}
+	if config.ClientSecretPath == "" && config.PrivateKeyPath == "" {
+		return nil, log.RecordedError(log.CAKC087)
+	}
+
+	source := &oidcSource{
+		config: config,
+		client: &http.Client{
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+			Timeout:   time.Second * 10,
+		},
+		now: time.Now,
+	}
+
+	return newCachedSource(TokenSourceOIDC, source.fetch), nil
+}
+
+func (source *oidcSource) fetch(ctx context.Context) (string, time.Time, error) {
+	logger := log.FromContext(ctx)
+	logger.Debug(log.CAKC088, source.config.TokenURL)
+
+	req, err := source.tokenRequest(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	resp, err := source.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC083, source.config.TokenURL, err)
+	}
+
+	err = utils.ValidateResponse(resp)
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC083, source.config.TokenURL, err)
+	}
+
+	body, err := utils.ReadResponseBody(resp)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var parsed tokenResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC083, source.config.TokenURL, err)
+	}
+
+	// With the client credentials grant the access token is normally the JWT,
+	// but some providers only issue a JWT as the ID token
+	for _, jwt := range []string{parsed.AccessToken, parsed.IDToken} {
+		claims, err := decodeClaims(jwt)
+		if err != nil {
+			continue
+		}
+
+		expiresAt, ok := timeClaim(claims, "exp")
+		if !ok {
+			expiresAt = source.now().Add(time.Duration(parsed.ExpiresIn) * time.Second)
+		}
+
+		logger.Debug(log.CAKC077)
+		return jwt, expiresAt, nil
+	}
+
+	return "", time.Time{}, logger.RecordedError(log.CAKC086, source.config.TokenURL)
+}
+
+// tokenRequest builds the client credentials grant request, including the
+// configured client authentication
+func (source *oidcSource) tokenRequest(ctx context.Context) (*http.Request, error) {
+	logger := log.FromContext(ctx)
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if source.config.Scope != "" {
+		form.Set("scope", source.config.Scope)
+	}
+	if source.config.Audience != "" {
+		form.Set("audience", source.config.Audience)
+	}
+
+	var clientSecret string
+	if source.config.PrivateKeyPath != "" {
+		key, err := loadSigningKey(source.config.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		assertion, err := signClientAssertion(
+			key,
+			source.config.KeyID,
+			source.config.ClientID,
+			source.config.TokenURL,
+			source.now(),
+		)
+		if err != nil {
+			return nil, logger.RecordedError(log.CAKC085, source.config.PrivateKeyPath, err)
+		}
+
+		form.Set("client_id", source.config.ClientID)
+		form.Set("client_assertion_type", clientAssertionType)
+		form.Set("client_assertion", assertion)
+	} else {
+		secret, err := os.ReadFile(source.config.ClientSecretPath)
+		if err != nil {
+			return nil, logger.RecordedError(log.CAKC084, source.config.ClientSecretPath)
+		}
+		clientSecret = strings.TrimSpace(string(secret))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", source.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, logger.RecordedError(log.CAKC083, source.config.TokenURL, err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if source.config.PrivateKeyPath == "" {
+		// RFC 6749 section 2.3.1 requires the credentials to be form-encoded
+		// before they are used for basic authentication
+		req.SetBasicAuth(url.QueryEscape(source.config.ClientID), url.QueryEscape(clientSecret))
+	}
+
+	return req, nil
+}
@@ -0,0 +1,259 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unsignedJWT builds a JWT with the given claims. The client never verifies
+// signatures, so a dummy one is enough for tests.
+func unsignedJWT(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+}
+
+type fakeTokenEndpoint struct {
+	server   *httptest.Server
+	requests int32
+}
+
+// newFakeTokenEndpoint starts a local OAuth2 token endpoint. The handler
+// validates the request and returns the token response to send.
+func newFakeTokenEndpoint(t *testing.T, handle func(r *http.Request) (int, interface{})) *fakeTokenEndpoint {
+	endpoint := &fakeTokenEndpoint{}
+	endpoint.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&endpoint.requests, 1)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+
+		status, body := handle(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(endpoint.server.Close)
+	return endpoint
+}
+
+func writeFile(t *testing.T, name string, content []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, content, 0600))
+	return path
+}
+
+func TestOIDCSource(t *testing.T) {
+	t.Run("client secret", func(t *testing.T) {
+		issued := unsignedJWT(map[string]interface{}{
+			"sub": "workload",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		endpoint := newFakeTokenEndpoint(t, func(r *http.Request) (int, interface{}) {
+			id, secret, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "my-client", id)
+			assert.Equal(t, "s3cr%2Bt", secret)
+			assert.Equal(t, "conjur", r.PostForm.Get("scope"))
+			return http.StatusOK, map[string]interface{}{
+				"access_token": issued,
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			}
+		})
+
+		source, err := newOIDCSource(OIDCConfig{
+			TokenURL:         endpoint.server.URL,
+			ClientID:         "my-client",
+			ClientSecretPath: writeFile(t, "secret", []byte("s3cr+t\n")),
+			Scope:            "conjur",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		token, err := source.token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, issued, token)
+
+		// The second call is served from the cache
+		token, err = source.token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, issued, token)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&endpoint.requests))
+	})
+
+	t.Run("token near expiry is fetched again", func(t *testing.T) {
+		endpoint := newFakeTokenEndpoint(t, func(r *http.Request) (int, interface{}) {
+			return http.StatusOK, map[string]interface{}{
+				"access_token": unsignedJWT(map[string]interface{}{
+					"exp": time.Now().Add(tokenExpiryBuffer / 2).Unix(),
+				}),
+			}
+		})
+
+		source, err := newOIDCSource(OIDCConfig{
+			TokenURL:         endpoint.server.URL,
+			ClientID:         "my-client",
+			ClientSecretPath: writeFile(t, "secret", []byte("secret")),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = source.token(context.Background())
+		assert.NoError(t, err)
+		_, err = source.token(context.Background())
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&endpoint.requests))
+	})
+
+	t.Run("expiry taken from expires_in when the JWT has no exp claim", func(t *testing.T) {
+		endpoint := newFakeTokenEndpoint(t, func(r *http.Request) (int, interface{}) {
+			return http.StatusOK, map[string]interface{}{
+				"access_token": unsignedJWT(map[string]interface{}{"sub": "workload"}),
+				"expires_in":   600,
+			}
+		})
+
+		source, err := newOIDCSource(OIDCConfig{
+			TokenURL:         endpoint.server.URL,
+			ClientID:         "my-client",
+			ClientSecretPath: writeFile(t, "secret", []byte("secret")),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = source.token(context.Background())
+		assert.NoError(t, err)
+		cached := source.(*cachedSource)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), cached.expiresAt, 5*time.Second)
+	})
+
+	t.Run("private_key_jwt", func(t *testing.T) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, _ := x509.MarshalECPrivateKey(key)
+		keyPath := writeFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+
+		var tokenURL string
+		endpoint := newFakeTokenEndpoint(t, func(r *http.Request) (int, interface{}) {
+			_, _, hasBasicAuth := r.BasicAuth()
+			assert.False(t, hasBasicAuth)
+			assert.Equal(t, "my-client", r.PostForm.Get("client_id"))
+			assert.Equal(t, clientAssertionType, r.PostForm.Get("client_assertion_type"))
+
+			assertion := r.PostForm.Get("client_assertion")
+			assertValidES256(t, assertion, &key.PublicKey)
+
+			claims, err := decodeClaims(assertion)
+			assert.NoError(t, err)
+			assert.Equal(t, "my-client", claims["iss"])
+			assert.Equal(t, "my-client", claims["sub"])
+			assert.Equal(t, tokenURL, claims["aud"])
+			assert.NotEmpty(t, claims["jti"])
+
+			return http.StatusOK, map[string]interface{}{
+				"access_token": unsignedJWT(map[string]interface{}{
+					"exp": time.Now().Add(time.Hour).Unix(),
+				}),
+			}
+		})
+		tokenURL = endpoint.server.URL
+
+		source, err := newOIDCSource(OIDCConfig{
+			TokenURL:       tokenURL,
+			ClientID:       "my-client",
+			PrivateKeyPath: keyPath,
+			KeyID:          "key-1",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = source.token(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		endpoint := newFakeTokenEndpoint(t, func(r *http.Request) (int, interface{}) {
+			return http.StatusUnauthorized, map[string]string{"error": "invalid_client"}
+		})
+
+		source, _ := newOIDCSource(OIDCConfig{
+			TokenURL:         endpoint.server.URL,
+			ClientID:         "my-client",
+			ClientSecretPath: writeFile(t, "secret", []byte("secret")),
+		})
+
+		_, err := source.token(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC083")
+	})
+
+	t.Run("response without a JWT", func(t *testing.T) {
+		endpoint := newFakeTokenEndpoint(t, func(r *http.Request) (int, interface{}) {
+			return http.StatusOK, map[string]interface{}{"access_token": "opaque-token"}
+		})
+
+		source, _ := newOIDCSource(OIDCConfig{
+			TokenURL:         endpoint.server.URL,
+			ClientID:         "my-client",
+			ClientSecretPath: writeFile(t, "secret", []byte("secret")),
+		})
+
+		_, err := source.token(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC086")
+	})
+
+	t.Run("missing client authentication", func(t *testing.T) {
+		_, err := newOIDCSource(OIDCConfig{
+			TokenURL: "https://idp.example.com/token",
+			ClientID: "my-client",
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC087")
+	})
+
+	t.Run("missing token URL", func(t *testing.T) {
+		_, err := newOIDCSource(OIDCConfig{ClientID: "my-client"})
+		assert.EqualError(t, err, fmt.Sprintf("CAKC009 Environment variable '%s' must be provided", "JWT_OIDC_TOKEN_URL"))
+	})
+}
+
+func assertValidES256(t *testing.T, token string, key *ecdsa.PublicKey) {
+	parts := strings.Split(token, ".")
+	if !assert.Len(t, parts, 3) {
+		return
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if !assert.NoError(t, err) || !assert.Len(t, signature, 64) {
+		return
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	assert.True(t, ecdsa.Verify(key, digest[:], r, s))
+}
@@ -1,34 +1,49 @@
 package jwt
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 )
 
 // AuthenticateRequest sends an authenticate request
 func AuthenticateRequest(authnURL string, account string, username string, jwtToken string) (*http.Request, error) {
+	return AuthenticateRequestWithContext(context.Background(), authnURL, account, username, jwtToken)
+}
+
+// AuthenticateRequestWithContext sends an authenticate request bound to ctx,
+// logging with its request logger
+func AuthenticateRequestWithContext(
+	ctx context.Context,
+	authnURL string,
+	account string,
+	username string,
+	jwtToken string,
+) (*http.Request, error) {
 	var err error
 	var req *http.Request
+	logger := log.FromContext(ctx)
 
 	var authenticateURL = createUrl(authnURL, account, username)
 
-	log.Debug(log.CAKC046, authenticateURL)
+	logger.Debug(log.CAKC046, authenticateURL)
 
 	formattedJwt := fmt.Sprintf("jwt=%s", jwtToken)
 	requestBody := strings.NewReader(formattedJwt)
 
-	if req, err = http.NewRequest("POST", authenticateURL, requestBody); err != nil {
-		return nil, log.RecordedError(log.CAKC023, err)
+	if req, err = http.NewRequestWithContext(ctx, "POST", authenticateURL, requestBody); err != nil {
+		return nil, logger.RecordedError(log.CAKC023, err)
 	}
 
 	req.Header.Set("Content-Type", "text/plain")
 	req.Header.Set("Content-Length", strconv.Itoa(len(formattedJwt)))
-	req.Header.Set("User-Agent", "k8s")
+	req.Header.Set("User-Agent", common.UserAgent(AuthnType))
 
 	return req, nil
 }
@@ -0,0 +1,233 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// tokenExpiryBuffer is how long before its expiry a cached JWT is replaced
+// with a fresh one.
+var tokenExpiryBuffer = 30 * time.Second
+
+// commandTimeout bounds how long the exec source waits for its command
+var commandTimeout = 30 * time.Second
+
+// tokenSource supplies the JWT that is sent to Conjur in the authenticate
+// request. Implementations must never log the token itself.
+type tokenSource interface {
+	// name identifies the source in logs, matching its JWT_TOKEN_SOURCE value
+	name() string
+	token(ctx context.Context) (string, error)
+}
+
+// fileSource reads the JWT from a file, e.g. a projected service account token
+type fileSource struct {
+	path string
+}
+
+func (source fileSource) name() string {
+	return TokenSourceFile
+}
+
+func (source fileSource) token(ctx context.Context) (string, error) {
+	return loadJWTToken(log.FromContext(ctx), source.path)
+}
+
+// envSource reads the JWT from an environment variable
+type envSource struct {
+	variable string
+	getenv   func(key string) string
+}
+
+func newEnvSource(variable string) (tokenSource, error) {
+	if variable == "" {
+		return nil, log.RecordedError(log.CAKC009, "JWT_TOKEN_ENV_VAR")
+	}
+	return envSource{variable: variable, getenv: os.Getenv}, nil
+}
+
+func (source envSource) name() string {
+	return TokenSourceEnv
+}
+
+func (source envSource) token(ctx context.Context) (string, error) {
+	logger := log.FromContext(ctx)
+	jwt := strings.TrimSpace(source.getenv(source.variable))
+	if jwt == "" {
+		return "", logger.RecordedError(log.CAKC100, source.variable)
+	}
+	return jwt, nil
+}
+
+// execSource runs a command and reads the JWT from its standard output. The
+// command is split on whitespace and run directly, without a shell.
+type execSource struct {
+	command []string
+}
+
+func newExecSource(command string) (tokenSource, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, log.RecordedError(log.CAKC009, "JWT_TOKEN_COMMAND")
+	}
+	return execSource{command: args}, nil
+}
+
+func (source execSource) name() string {
+	return TokenSourceExec
+}
+
+func (source execSource) token(ctx context.Context) (string, error) {
+	logger := log.FromContext(ctx)
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, source.command[0], source.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		reason := err.Error()
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			reason = fmt.Sprintf("%s: %s", reason, message)
+		}
+		return "", logger.RecordedError(log.CAKC101, source.command[0], reason)
+	}
+
+	jwt := strings.TrimSpace(stdout.String())
+	if jwt == "" {
+		return "", logger.RecordedError(log.CAKC102, source.command[0])
+	}
+	return jwt, nil
+}
+
+// fetchFunc obtains a new JWT together with the time at which it expires
+type fetchFunc func(ctx context.Context) (string, time.Time, error)
+
+// cachedSource holds on to a fetched JWT and only fetches a new one once the
+// cached token is about to expire
+type cachedSource struct {
+	sourceName string
+	fetch      fetchFunc
+	now        func() time.Time
+	mutex      sync.Mutex
+	jwt        string
+	expiresAt  time.Time
+}
+
+func newCachedSource(name string, fetch fetchFunc) *cachedSource {
+	return &cachedSource{
+		sourceName: name,
+		fetch:      fetch,
+		now:        time.Now,
+	}
+}
+
+func (source *cachedSource) name() string {
+	return source.sourceName
+}
+
+func (source *cachedSource) token(ctx context.Context) (string, error) {
+	logger := log.FromContext(ctx)
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.jwt != "" && source.now().Add(tokenExpiryBuffer).Before(source.expiresAt) {
+		logger.Debug(log.CAKC089, source.expiresAt)
+		return source.jwt, nil
+	}
+
+	jwt, expiresAt, err := source.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	source.jwt = jwt
+	source.expiresAt = expiresAt
+	return jwt, nil
+}
+
+// fallbackSource tries each of its sources in order and returns the first
+// JWT obtained
+type fallbackSource struct {
+	sources []tokenSource
+}
+
+func (source fallbackSource) name() string {
+	names := make([]string, len(source.sources))
+	for i, s := range source.sources {
+		names[i] = s.name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (source fallbackSource) token(ctx context.Context) (string, error) {
+	logger := log.FromContext(ctx)
+	var err error
+	for i, s := range source.sources {
+		var jwt string
+		jwt, err = s.token(ctx)
+		if err == nil {
+			logger.Info(log.CAKC097, s.name())
+			return jwt, nil
+		}
+
+		if i < len(source.sources)-1 {
+			logger.Warn(log.CAKC098, s.name())
+		}
+	}
+
+	// With a single source its own error is the most useful one
+	if len(source.sources) == 1 {
+		return "", err
+	}
+	return "", logger.RecordedError(log.CAKC099, source.name())
+}
+
+// newTokenSource returns the sources listed in JWT_TOKEN_SOURCE, to be tried
+// in the listed order
+func newTokenSource(config Config) (tokenSource, error) {
+	names := config.JWTTokenSources
+	if len(names) == 0 {
+		names = []string{TokenSourceFile}
+	}
+
+	sources := make([]tokenSource, 0, len(names))
+	for _, name := range names {
+		source, err := newNamedSource(name, config)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	return fallbackSource{sources: sources}, nil
+}
+
+func newNamedSource(name string, config Config) (tokenSource, error) {
+	switch name {
+	case TokenSourceFile:
+		return fileSource{path: config.JWTTokenFilePath}, nil
+	case TokenSourceEnv:
+		return newEnvSource(config.JWTTokenEnvVar)
+	case TokenSourceExec:
+		return newExecSource(config.JWTTokenCommand)
+	case TokenSourceOIDC:
+		return newOIDCSource(config.OIDC)
+	case TokenSourceTokenRequest:
+		return newTokenRequestSource(config.TokenRequest)
+	case TokenSourceSPIFFE:
+		return newSPIFFESource(config.SPIFFE)
+	default:
+		return nil, log.RecordedError(log.CAKC060, "JWT_TOKEN_SOURCE", name)
+	}
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// writeScript creates an executable shell script to be run by the exec source
+func writeScript(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "get-jwt")
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700))
+	return path
+}
+
+func captureInfoLog(t *testing.T) *bytes.Buffer {
+	var logTxt bytes.Buffer
+	log.InfoLogger.SetOutput(&logTxt)
+	t.Cleanup(func() { log.InfoLogger.SetOutput(os.Stdout) })
+	return &logTxt
+}
+
+func TestEnvSource(t *testing.T) {
+	source := envSource{
+		variable: "CI_JOB_JWT",
+		getenv: func(key string) string {
+			return map[string]string{"CI_JOB_JWT": "env.jwt.token\n"}[key]
+		},
+	}
+
+	token, err := source.token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "env.jwt.token", token)
+
+	source.variable = "UNSET_JWT"
+	_, err = source.token(context.Background())
+	assert.Contains(t, err.Error(), "CAKC100")
+
+	_, err = newEnvSource("")
+	assert.Contains(t, err.Error(), "CAKC009")
+}
+
+func TestExecSource(t *testing.T) {
+	t.Run("reads the JWT from stdout", func(t *testing.T) {
+		source, err := newExecSource(writeScript(t, `echo "exec.jwt.$1"`) + " token")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		token, err := source.token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "exec.jwt.token", token)
+	})
+
+	t.Run("command fails", func(t *testing.T) {
+		source, _ := newExecSource(writeScript(t, "echo 'not logged in' >&2; exit 3"))
+
+		_, err := source.token(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC101")
+		assert.Contains(t, err.Error(), "not logged in")
+	})
+
+	t.Run("command prints nothing", func(t *testing.T) {
+		source, _ := newExecSource(writeScript(t, "exit 0"))
+
+		_, err := source.token(context.Background())
+		assert.Contains(t, err.Error(), "CAKC102")
+	})
+
+	t.Run("command times out", func(t *testing.T) {
+		defer func(timeout time.Duration) { commandTimeout = timeout }(commandTimeout)
+		commandTimeout = 100 * time.Millisecond
+
+		source, _ := newExecSource(writeScript(t, "exec sleep 5"))
+
+		_, err := source.token(context.Background())
+		assert.Contains(t, err.Error(), "CAKC101")
+	})
+
+	t.Run("empty command", func(t *testing.T) {
+		_, err := newExecSource("  ")
+		assert.Contains(t, err.Error(), "CAKC009")
+	})
+}
+
+func TestNewTokenSource(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "jwt")
+	assert.NoError(t, os.WriteFile(tokenPath, []byte("file.jwt.token"), 0600))
+
+	t.Run("defaults to the file source", func(t *testing.T) {
+		source, err := newTokenSource(Config{JWTTokenFilePath: tokenPath})
+		assert.NoError(t, err)
+		assert.Equal(t, "file", source.name())
+	})
+
+	t.Run("falls back to the next source in order", func(t *testing.T) {
+		logTxt := captureInfoLog(t)
+
+		source, err := newTokenSource(Config{
+			JWTTokenFilePath: tokenPath,
+			JWTTokenSources:  []string{"exec", "file"},
+			JWTTokenCommand:  writeScript(t, "exit 1"),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		token, err := source.token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "file.jwt.token", token)
+
+		assert.Contains(t, logTxt.String(), `CAKC098 Failed to obtain JWT from the "exec" source`)
+		assert.Contains(t, logTxt.String(), `CAKC097 JWT supplied by the "file" source`)
+		assert.NotContains(t, logTxt.String(), "file.jwt.token")
+	})
+
+	t.Run("first source that succeeds wins", func(t *testing.T) {
+		logTxt := captureInfoLog(t)
+
+		source, _ := newTokenSource(Config{
+			JWTTokenFilePath: tokenPath,
+			JWTTokenSources:  []string{"exec", "file"},
+			JWTTokenCommand:  writeScript(t, "echo exec.jwt.token"),
+		})
+
+		token, err := source.token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "exec.jwt.token", token)
+		assert.Contains(t, logTxt.String(), `CAKC097 JWT supplied by the "exec" source`)
+		assert.NotContains(t, logTxt.String(), "exec.jwt.token")
+	})
+
+	t.Run("all sources fail", func(t *testing.T) {
+		source, _ := newTokenSource(Config{
+			JWTTokenFilePath: "/nonexistent/jwt",
+			JWTTokenSources:  []string{"exec", "file"},
+			JWTTokenCommand:  writeScript(t, "exit 1"),
+		})
+
+		_, err := source.token(context.Background())
+		assert.EqualError(t, err, "CAKC099 Failed to obtain JWT from any of the configured sources: exec,file")
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		_, err := newTokenSource(Config{JWTTokenSources: []string{"file", "vault"}})
+		assert.EqualError(t, err, "CAKC060 Setting JWT_TOKEN_SOURCE given invalid value vault")
+	})
+}
+
+func TestParseTokenSources(t *testing.T) {
+	assert.Equal(t, []string{"env", "file"}, ParseTokenSources(" env, file ,"))
+	assert.Empty(t, ParseTokenSources(""))
+}
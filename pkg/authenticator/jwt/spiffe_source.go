@@ -0,0 +1,64 @@
+package jwt
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// spiffeSource fetches a JWT-SVID from the SPIFFE Workload API, e.g. the
+// socket exposed by a SPIRE agent
+type spiffeSource struct {
+	config SPIFFEConfig
+	params jwtsvid.Params
+}
+
+func newSPIFFESource(config SPIFFEConfig) (tokenSource, error) {
+	if config.Audience == "" {
+		return nil, log.RecordedError(log.CAKC009, "JWT_SPIFFE_AUDIENCE")
+	}
+	if config.EndpointSocket == "" {
+		return nil, log.RecordedError(log.CAKC009, "SPIFFE_ENDPOINT_SOCKET")
+	}
+
+	params := jwtsvid.Params{Audience: config.Audience}
+	if config.SPIFFEID != "" {
+		id, err := spiffeid.FromString(config.SPIFFEID)
+		if err != nil {
+			return nil, log.RecordedError(log.CAKC060, "JWT_SPIFFE_ID", config.SPIFFEID)
+		}
+		params.Subject = id
+	}
+
+	source := &spiffeSource{
+		config: config,
+		params: params,
+	}
+
+	return newCachedSource(TokenSourceSPIFFE, source.fetch), nil
+}
+
+func (source *spiffeSource) fetch(ctx context.Context) (string, time.Time, error) {
+	logger := log.FromContext(ctx)
+	logger.Debug(log.CAKC095, source.config.Audience, source.config.EndpointSocket)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	svid, err := workloadapi.FetchJWTSVID(
+		ctx,
+		source.params,
+		workloadapi.WithAddr(source.config.EndpointSocket),
+	)
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC096, source.config.EndpointSocket, err)
+	}
+
+	logger.Debug(log.CAKC077)
+	return svid.Marshal(), svid.Expiry, nil
+}
@@ -0,0 +1,177 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeWorkloadAPI stands in for a SPIRE agent, issuing JWT-SVIDs signed with
+// a throwaway key
+type fakeWorkloadAPI struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+
+	key      *ecdsa.PrivateKey
+	spiffeID string
+	lifetime time.Duration
+	requests int32
+}
+
+func (api *fakeWorkloadAPI) FetchJWTSVID(ctx context.Context, req *workload.JWTSVIDRequest) (*workload.JWTSVIDResponse, error) {
+	atomic.AddInt32(&api.requests, 1)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("workload.spiffe.io")) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "security header missing from request")
+	}
+	if req.SpiffeId != "" && req.SpiffeId != api.spiffeID {
+		return nil, status.Error(codes.PermissionDenied, "no identity issued")
+	}
+
+	svid, err := signJWT(api.key, "", map[string]interface{}{
+		"sub": api.spiffeID,
+		"aud": req.Audience,
+		"exp": time.Now().Add(api.lifetime).Unix(),
+		"iat": time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &workload.JWTSVIDResponse{
+		Svids: []*workload.JWTSVID{{SpiffeId: api.spiffeID, Svid: svid}},
+	}, nil
+}
+
+// startFakeWorkloadAPI serves the fake Workload API on a Unix socket and
+// returns its address
+func startFakeWorkloadAPI(t *testing.T, api *fakeWorkloadAPI) string {
+	// Unix socket paths are limited in length, so avoid the long t.TempDir()
+	dir, err := os.MkdirTemp("", "spiffe")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	socketPath := filepath.Join(dir, "agent.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	server := grpc.NewServer()
+	workload.RegisterSpiffeWorkloadAPIServer(server, api)
+	go server.Serve(listener)
+
+	t.Cleanup(func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	})
+
+	return "unix://" + socketPath
+}
+
+func TestSPIFFESource(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	t.Run("fetches and caches a JWT-SVID", func(t *testing.T) {
+		api := &fakeWorkloadAPI{
+			key:      key,
+			spiffeID: "spiffe://example.org/ns/apps/sa/my-app",
+			lifetime: time.Hour,
+		}
+		addr := startFakeWorkloadAPI(t, api)
+
+		source, err := newSPIFFESource(SPIFFEConfig{
+			EndpointSocket: addr,
+			Audience:       "conjur",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		token, err := source.token(context.Background())
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		claims, err := decodeClaims(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "spiffe://example.org/ns/apps/sa/my-app", claims["sub"])
+		assert.Equal(t, []interface{}{"conjur"}, claims["aud"])
+
+		_, err = source.token(context.Background())
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&api.requests))
+	})
+
+	t.Run("refreshes the JWT-SVID before it expires", func(t *testing.T) {
+		api := &fakeWorkloadAPI{
+			key:      key,
+			spiffeID: "spiffe://example.org/my-app",
+			lifetime: tokenExpiryBuffer / 2,
+		}
+		addr := startFakeWorkloadAPI(t, api)
+
+		source, _ := newSPIFFESource(SPIFFEConfig{
+			EndpointSocket: addr,
+			Audience:       "conjur",
+		})
+
+		_, err := source.token(context.Background())
+		assert.NoError(t, err)
+		_, err = source.token(context.Background())
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&api.requests))
+	})
+
+	t.Run("requested SPIFFE ID is not issued", func(t *testing.T) {
+		api := &fakeWorkloadAPI{
+			key:      key,
+			spiffeID: "spiffe://example.org/my-app",
+			lifetime: time.Hour,
+		}
+		addr := startFakeWorkloadAPI(t, api)
+
+		source, err := newSPIFFESource(SPIFFEConfig{
+			EndpointSocket: addr,
+			Audience:       "conjur",
+			SPIFFEID:       "spiffe://example.org/other-app",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = source.token(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC096")
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := newSPIFFESource(SPIFFEConfig{EndpointSocket: "unix:///tmp/agent.sock"})
+		assert.Contains(t, err.Error(), "CAKC009")
+
+		_, err = newSPIFFESource(SPIFFEConfig{Audience: "conjur"})
+		assert.Contains(t, err.Error(), "CAKC009")
+
+		_, err = newSPIFFESource(SPIFFEConfig{
+			EndpointSocket: "unix:///tmp/agent.sock",
+			Audience:       "conjur",
+			SPIFFEID:       "not-a-spiffe-id",
+		})
+		assert.Contains(t, err.Error(), "CAKC060")
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/pem"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -50,6 +52,14 @@ func TestAuthenticator_Authenticate(t *testing.T) {
 			},
 			wrongUrl: true,
 		},
+		{
+			name:         "token has expired",
+			jwtTokenPath: "expired_jwt.token",
+			assert: func(t *testing.T, authn *jwt.Authenticator, err error) {
+				assert.NotNil(t, err)
+				assert.True(t, strings.Contains(err.Error(), "CAKC103 JWT expired at"))
+			},
+		},
 		{
 			name: "token doesn't exist",
 			assert: func(t *testing.T, authn *jwt.Authenticator, err error) {
@@ -79,6 +89,10 @@ func TestAuthenticator_Authenticate(t *testing.T) {
 
 			// Start up a test server to mock the Conjur server's auth endpoints
 			ts := common.NewTestAuthServer(clientCertPath, certLogPath, "some token", tc.skipWritingCSRFile)
+			var requestHeader http.Header
+			ts.HandleRequest = func(r *http.Request) {
+				requestHeader = r.Header.Clone()
+			}
 
 			defer ts.Server.Close()
 
@@ -118,12 +132,29 @@ func TestAuthenticator_Authenticate(t *testing.T) {
 			// Intercept the logs to check for the cert placement error
 			var logTxt bytes.Buffer
 			log.ErrorLogger.SetOutput(&logTxt)
+			log.SetLogLevel("debug")
+			defer log.SetLogLevel("info")
+			var infoTxt bytes.Buffer
+			log.InfoLogger.SetOutput(&infoTxt)
+			defer log.InfoLogger.SetOutput(os.Stdout)
 
 			// Call the main method of the authenticator. This is where most of the internal implementation happens
 			err = authn.AuthenticateWithContext(context.Background())
 
 			// ASSERT
 			tc.assert(t, authn, err)
+
+			// Every message of the attempt, including those of the helpers,
+			// is tagged with its request ID
+			for _, line := range strings.Split(strings.TrimSpace(logTxt.String()+infoTxt.String()), "\n") {
+				assert.Contains(t, line, "[request_id=")
+			}
+
+			// The authenticate request identifies the client and the attempt
+			if err == nil {
+				assert.Equal(t, common.UserAgent(jwt.AuthnType), requestHeader.Get("User-Agent"))
+				assert.Len(t, requestHeader.Get(common.RequestIDHeader), 32)
+			}
 		})
 	}
 }
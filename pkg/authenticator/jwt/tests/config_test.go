@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/config"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/jwt"
 )
@@ -50,34 +51,112 @@ func TestGatherSettings(t *testing.T) {
 			description: "functions are ordered by priority: first function overrides second, which overrides third",
 			annotFunc:   fromAnnotations,
 			expected: config.AuthnSettings{
-				"JWT_TOKEN_PATH":          "good_jwt.token",
-				"CONJUR_AUTHN_LOGIN":      "",
-				"CONJUR_ACCOUNT":          "testAccount",
-				"CONJUR_AUTHN_URL":        "authn-jwt",
-				"CONJUR_CERT_FILE":        "testSSLCertFile.txt",
-				"CONJUR_SSL_CERTIFICATE":  "testSSLCert",
-				"CONTAINER_MODE":          "init",  // provided by annotation
-				"LOG_LEVEL":               "debug", // provided by annotation
-				"DEBUG":                   "",
-				"CONJUR_AUTHN_TOKEN_FILE": jwt.DefaultTokenFilePath,
-				"CONJUR_TOKEN_TIMEOUT":    jwt.DefaultTokenRefreshTimeout,
+				"JWT_TOKEN_PATH":               "good_jwt.token",
+				"CONJUR_AUTHN_LOGIN":           "",
+				"CONJUR_ACCOUNT":               "testAccount",
+				"CONJUR_AUTHN_URL":             "authn-jwt",
+				"CONJUR_CERT_FILE":             "testSSLCertFile.txt",
+				"CONJUR_CA_SYSTEM_ROOTS":       "",
+				"CONJUR_CONNECT_TIMEOUT":       "10s",
+				"CONJUR_HTTP_HEADERS":          "",
+				"CONJUR_HTTP_TIMEOUT":          "10s",
+				"CONJUR_PROXY_CA_FILE":         "",
+				"CONJUR_PROXY_URL":             "",
+				"CONJUR_TLS_HANDSHAKE_TIMEOUT": "10s",
+				"CONJUR_TLS_MIN_VERSION":       "1.2",
+				"CONJUR_TLS_SERVER_NAME":       "",
+				"CONJUR_SSL_BOOTSTRAP":         "",
+				"CONJUR_SSL_PINS":              "",
+				"CONJUR_SSL_PIN_ONLY":          "",
+				"CONJUR_SSL_CERTIFICATE":       "testSSLCert",
+				"CONTAINER_MODE":               "init",  // provided by annotation
+				"LOG_LEVEL":                    "debug", // provided by annotation
+				"DEBUG":                        "",
+				"CONJUR_AUTHN_TOKEN_FILE":      jwt.DefaultTokenFilePath,
+				"CONJUR_TOKEN_TIMEOUT":         jwt.DefaultTokenRefreshTimeout,
+				"JWT_TOKEN_SOURCE":             jwt.TokenSourceFile,
+				"JWT_TOKEN_ENV_VAR":            "",
+				"JWT_TOKEN_COMMAND":            "",
+				"JWT_OIDC_TOKEN_URL":           "",
+				"JWT_OIDC_CLIENT_ID":           "",
+				"JWT_OIDC_CLIENT_SECRET_PATH":  "",
+				"JWT_OIDC_PRIVATE_KEY_PATH":    "",
+				"JWT_OIDC_KEY_ID":              "",
+				"JWT_OIDC_SCOPE":               "",
+				"JWT_OIDC_AUDIENCE":            "",
+				"JWT_TOKEN_REQUEST_API_URL":    jwt.DefaultKubernetesAPIURL,
+				"JWT_TOKEN_REQUEST_AUDIENCE":   "",
+				"JWT_TOKEN_REQUEST_EXPIRATION": jwt.DefaultTokenRequestExpiration,
+				"MY_POD_NAMESPACE":             "",
+				"MY_POD_SERVICE_ACCOUNT":       "",
+				"JWT_SPIFFE_AUDIENCE":          "",
+				"JWT_SPIFFE_ID":                "",
+				"SPIFFE_ENDPOINT_SOCKET":       "",
+				"JWT_CLOCK_SKEW":               jwt.DefaultJWTClockSkew,
+				"JWT_EXPECTED_ISSUER":          "",
+				"JWT_EXPECTED_AUDIENCE":        "",
+				"JWT_IDENTITY_CLAIM":           "",
+				"CONJUR_POD_INFO_PATH":         common.DefaultPodInfoPath,
+				"CONJUR_ANNOTATIONS_FILE":      "",
+				"CONJUR_CONFIG_FILE":           "",
+				"CONJUR_STRICT_SETTINGS":       "",
+				"MY_POD_DEPLOYMENT":            "",
 			},
 		},
 		{
 			description: "if the first getter function returns empty strings, fallback to the next functions, and eventually an empty string",
 			annotFunc:   emptyAnnotations,
 			expected: config.AuthnSettings{
-				"JWT_TOKEN_PATH":          "good_jwt.token",
-				"CONJUR_AUTHN_LOGIN":      "",
-				"CONJUR_AUTHN_URL":        "authn-jwt",
-				"CONJUR_ACCOUNT":          "testAccount",
-				"CONJUR_CERT_FILE":        "testSSLCertFile.txt",
-				"CONJUR_SSL_CERTIFICATE":  "testSSLCert",
-				"LOG_LEVEL":               "",
-				"DEBUG":                   "",
-				"CONTAINER_MODE":          "",
-				"CONJUR_AUTHN_TOKEN_FILE": jwt.DefaultTokenFilePath,
-				"CONJUR_TOKEN_TIMEOUT":    jwt.DefaultTokenRefreshTimeout,
+				"JWT_TOKEN_PATH":               "good_jwt.token",
+				"CONJUR_AUTHN_LOGIN":           "",
+				"CONJUR_AUTHN_URL":             "authn-jwt",
+				"CONJUR_ACCOUNT":               "testAccount",
+				"CONJUR_CERT_FILE":             "testSSLCertFile.txt",
+				"CONJUR_CA_SYSTEM_ROOTS":       "",
+				"CONJUR_CONNECT_TIMEOUT":       "10s",
+				"CONJUR_HTTP_HEADERS":          "",
+				"CONJUR_HTTP_TIMEOUT":          "10s",
+				"CONJUR_PROXY_CA_FILE":         "",
+				"CONJUR_PROXY_URL":             "",
+				"CONJUR_TLS_HANDSHAKE_TIMEOUT": "10s",
+				"CONJUR_TLS_MIN_VERSION":       "1.2",
+				"CONJUR_TLS_SERVER_NAME":       "",
+				"CONJUR_SSL_BOOTSTRAP":         "",
+				"CONJUR_SSL_PINS":              "",
+				"CONJUR_SSL_PIN_ONLY":          "",
+				"CONJUR_SSL_CERTIFICATE":       "testSSLCert",
+				"LOG_LEVEL":                    "",
+				"DEBUG":                        "",
+				"CONTAINER_MODE":               "",
+				"CONJUR_AUTHN_TOKEN_FILE":      jwt.DefaultTokenFilePath,
+				"CONJUR_TOKEN_TIMEOUT":         jwt.DefaultTokenRefreshTimeout,
+				"JWT_TOKEN_SOURCE":             jwt.TokenSourceFile,
+				"JWT_TOKEN_ENV_VAR":            "",
+				"JWT_TOKEN_COMMAND":            "",
+				"JWT_OIDC_TOKEN_URL":           "",
+				"JWT_OIDC_CLIENT_ID":           "",
+				"JWT_OIDC_CLIENT_SECRET_PATH":  "",
+				"JWT_OIDC_PRIVATE_KEY_PATH":    "",
+				"JWT_OIDC_KEY_ID":              "",
+				"JWT_OIDC_SCOPE":               "",
+				"JWT_OIDC_AUDIENCE":            "",
+				"JWT_TOKEN_REQUEST_API_URL":    jwt.DefaultKubernetesAPIURL,
+				"JWT_TOKEN_REQUEST_AUDIENCE":   "",
+				"JWT_TOKEN_REQUEST_EXPIRATION": jwt.DefaultTokenRequestExpiration,
+				"MY_POD_NAMESPACE":             "",
+				"MY_POD_SERVICE_ACCOUNT":       "",
+				"JWT_SPIFFE_AUDIENCE":          "",
+				"JWT_SPIFFE_ID":                "",
+				"SPIFFE_ENDPOINT_SOCKET":       "",
+				"JWT_CLOCK_SKEW":               jwt.DefaultJWTClockSkew,
+				"JWT_EXPECTED_ISSUER":          "",
+				"JWT_EXPECTED_AUDIENCE":        "",
+				"JWT_IDENTITY_CLAIM":           "",
+				"CONJUR_POD_INFO_PATH":         common.DefaultPodInfoPath,
+				"CONJUR_ANNOTATIONS_FILE":      "",
+				"CONJUR_CONFIG_FILE":           "",
+				"CONJUR_STRICT_SETTINGS":       "",
+				"MY_POD_DEPLOYMENT":            "",
 			},
 		},
 	}
@@ -0,0 +1,175 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/utils"
+)
+
+// minTokenRequestExpiration is the shortest token lifetime the Kubernetes API
+// server accepts in a TokenRequest
+const minTokenRequestExpiration = 10 * time.Minute
+
+// tokenRequestSource mints short-lived, audience-scoped tokens for the pod's
+// own service account using the Kubernetes TokenRequest API. It authenticates
+// to the API server with the service account token mounted in the pod.
+type tokenRequestSource struct {
+	config TokenRequestConfig
+	client *http.Client
+}
+
+type tokenRequest struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Spec       tokenRequestSpec `json:"spec"`
+}
+
+type tokenRequestSpec struct {
+	Audiences         []string `json:"audiences"`
+	ExpirationSeconds int64    `json:"expirationSeconds"`
+}
+
+type tokenRequestResponse struct {
+	Status struct {
+		Token               string    `json:"token"`
+		ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+func newTokenRequestSource(config TokenRequestConfig) (tokenSource, error) {
+	if config.Audience == "" {
+		return nil, log.RecordedError(log.CAKC009, "JWT_TOKEN_REQUEST_AUDIENCE")
+	}
+	if config.Expiration < minTokenRequestExpiration {
+		return nil, log.RecordedError(log.CAKC060, "JWT_TOKEN_REQUEST_EXPIRATION", config.Expiration)
+	}
+	if config.APIURL == "" {
+		config.APIURL = DefaultKubernetesAPIURL
+	}
+	if config.ServiceAccountDir == "" {
+		config.ServiceAccountDir = DefaultServiceAccountDir
+	}
+
+	tlsConfig := &tls.Config{}
+	caPath := filepath.Join(config.ServiceAccountDir, "ca.crt")
+	if caCert, err := os.ReadFile(caPath); err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, log.RecordedError(log.CAKC093, caPath, "no certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	source := &tokenRequestSource{
+		config: config,
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+			Timeout:   time.Second * 10,
+		},
+	}
+
+	return newCachedSource(TokenSourceTokenRequest, source.fetch), nil
+}
+
+func (source *tokenRequestSource) fetch(ctx context.Context) (string, time.Time, error) {
+	logger := log.FromContext(ctx)
+	logger.Debug(log.CAKC091, source.config.Audience, source.config.APIURL)
+
+	credentialPath := filepath.Join(source.config.ServiceAccountDir, "token")
+	credential, err := os.ReadFile(credentialPath)
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC093, credentialPath, err)
+	}
+	bearer := strings.TrimSpace(string(credential))
+
+	namespace, serviceAccount, err := source.serviceAccount(logger, bearer)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	body, err := json.Marshal(tokenRequest{
+		APIVersion: "authentication.k8s.io/v1",
+		Kind:       "TokenRequest",
+		Spec: tokenRequestSpec{
+			Audiences:         []string{source.config.Audience},
+			ExpirationSeconds: int64(source.config.Expiration.Seconds()),
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC092, source.config.APIURL, err)
+	}
+
+	requestURL := fmt.Sprintf(
+		"%s/api/v1/namespaces/%s/serviceaccounts/%s/token",
+		strings.TrimSuffix(source.config.APIURL, "/"),
+		url.PathEscape(namespace),
+		url.PathEscape(serviceAccount),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC092, source.config.APIURL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bearer)
+
+	resp, err := source.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC092, source.config.APIURL, err)
+	}
+
+	err = utils.ValidateResponse(resp)
+	if err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC092, source.config.APIURL, err)
+	}
+
+	responseBody, err := utils.ReadResponseBody(resp)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var parsed tokenRequestResponse
+	if err := json.Unmarshal(responseBody, &parsed); err != nil {
+		return "", time.Time{}, logger.RecordedError(log.CAKC092, source.config.APIURL, err)
+	}
+	if parsed.Status.Token == "" {
+		return "", time.Time{}, logger.RecordedError(log.CAKC092, source.config.APIURL, "response contains no token")
+	}
+
+	logger.Debug(log.CAKC077)
+	return parsed.Status.Token, parsed.Status.ExpirationTimestamp, nil
+}
+
+// serviceAccount returns the namespace and name of the pod's service account.
+// If they are not configured they are taken from the subject of the mounted
+// service account token, which has the form
+// system:serviceaccount:<namespace>:<name>.
+func (source *tokenRequestSource) serviceAccount(logger log.RequestLogger, bearer string) (string, string, error) {
+	if source.config.Namespace != "" && source.config.ServiceAccount != "" {
+		return source.config.Namespace, source.config.ServiceAccount, nil
+	}
+
+	claims, err := decodeClaims(bearer)
+	if err == nil {
+		subject, _ := claims["sub"].(string)
+		parts := strings.Split(subject, ":")
+		if len(parts) == 4 && parts[0] == "system" && parts[1] == "serviceaccount" {
+			return parts[2], parts[3], nil
+		}
+	}
+
+	return "", "", logger.RecordedError(log.CAKC094)
+}
@@ -0,0 +1,155 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeAPIServer starts a TLS server mocking the TokenRequest endpoint of
+// the Kubernetes API, and writes the service account credentials trusted by
+// it into a temporary directory.
+func newFakeAPIServer(t *testing.T, bearer string, handle func(r *http.Request, req tokenRequest) (int, interface{})) (*httptest.Server, string) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "Bearer "+bearer, r.Header.Get("Authorization"))
+
+		var req tokenRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "TokenRequest", req.Kind)
+
+		status, body := handle(r, req)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), caCert, 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte(bearer+"\n"), 0600))
+
+	return server, dir
+}
+
+func TestTokenRequestSource(t *testing.T) {
+	bearer := unsignedJWT(map[string]interface{}{
+		"sub": "system:serviceaccount:apps:my-sa",
+		"aud": []string{"https://kubernetes.default.svc"},
+	})
+
+	t.Run("mints a token for the pod's service account", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		requests := 0
+
+		server, dir := newFakeAPIServer(t, bearer, func(r *http.Request, req tokenRequest) (int, interface{}) {
+			requests++
+			assert.Equal(t, "/api/v1/namespaces/apps/serviceaccounts/my-sa/token", r.URL.Path)
+			assert.Equal(t, []string{"conjur"}, req.Spec.Audiences)
+			assert.EqualValues(t, 3600, req.Spec.ExpirationSeconds)
+
+			return http.StatusCreated, map[string]interface{}{
+				"status": map[string]interface{}{
+					"token":               "minted-token",
+					"expirationTimestamp": expiresAt.Format(time.RFC3339),
+				},
+			}
+		})
+
+		source, err := newTokenRequestSource(TokenRequestConfig{
+			APIURL:            server.URL,
+			Audience:          "conjur",
+			Expiration:        time.Hour,
+			ServiceAccountDir: dir,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		token, err := source.token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "minted-token", token)
+		assert.True(t, expiresAt.Equal(source.(*cachedSource).expiresAt))
+
+		// The minted token is reused until it nears expiry
+		_, err = source.token(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("configured service account takes precedence", func(t *testing.T) {
+		server, dir := newFakeAPIServer(t, bearer, func(r *http.Request, req tokenRequest) (int, interface{}) {
+			assert.Equal(t, "/api/v1/namespaces/other-ns/serviceaccounts/other-sa/token", r.URL.Path)
+			return http.StatusCreated, map[string]interface{}{
+				"status": map[string]interface{}{
+					"token":               "minted-token",
+					"expirationTimestamp": time.Now().Add(time.Hour).Format(time.RFC3339),
+				},
+			}
+		})
+
+		source, _ := newTokenRequestSource(TokenRequestConfig{
+			APIURL:            server.URL,
+			Audience:          "conjur",
+			Expiration:        time.Hour,
+			Namespace:         "other-ns",
+			ServiceAccount:    "other-sa",
+			ServiceAccountDir: dir,
+		})
+
+		_, err := source.token(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("forbidden by RBAC", func(t *testing.T) {
+		server, dir := newFakeAPIServer(t, bearer, func(r *http.Request, req tokenRequest) (int, interface{}) {
+			return http.StatusForbidden, map[string]interface{}{"message": "cannot create resource"}
+		})
+
+		source, _ := newTokenRequestSource(TokenRequestConfig{
+			APIURL:            server.URL,
+			Audience:          "conjur",
+			Expiration:        time.Hour,
+			ServiceAccountDir: dir,
+		})
+
+		_, err := source.token(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC092")
+		assert.Contains(t, err.Error(), "status code 403")
+	})
+
+	t.Run("service account cannot be determined", func(t *testing.T) {
+		server, dir := newFakeAPIServer(t, "opaque", func(r *http.Request, req tokenRequest) (int, interface{}) {
+			return http.StatusCreated, nil
+		})
+
+		source, _ := newTokenRequestSource(TokenRequestConfig{
+			APIURL:            server.URL,
+			Audience:          "conjur",
+			Expiration:        time.Hour,
+			ServiceAccountDir: dir,
+		})
+
+		_, err := source.token(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC094")
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := newTokenRequestSource(TokenRequestConfig{Expiration: time.Hour})
+		assert.Contains(t, err.Error(), "CAKC009")
+
+		_, err = newTokenRequestSource(TokenRequestConfig{Audience: "conjur", Expiration: time.Minute})
+		assert.Contains(t, err.Error(), "CAKC060")
+	})
+}
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// validateClaims checks the claims of the JWT before it is sent to Conjur, so
+// that an expired or mismatched token is reported with a specific error
+// rather than the generic 401 returned by the server. The signature is not
+// verified: Conjur does that.
+func validateClaims(logger log.RequestLogger, token string, config ValidationConfig, checkIdentity bool, now time.Time) error {
+	claims, err := decodeClaims(token)
+	if err != nil {
+		return logger.RecordedError(log.CAKC109, err)
+	}
+
+	skew := config.ClockSkew
+	if expiresAt, ok := timeClaim(claims, "exp"); ok && !now.Before(expiresAt.Add(skew)) {
+		return logger.RecordedError(log.CAKC103, expiresAt.UTC(), now.UTC(), skew)
+	}
+	if notBefore, ok := timeClaim(claims, "nbf"); ok && now.Add(skew).Before(notBefore) {
+		return logger.RecordedError(log.CAKC104, notBefore.UTC(), now.UTC(), skew)
+	}
+	if issuedAt, ok := timeClaim(claims, "iat"); ok && now.Add(skew).Before(issuedAt) {
+		return logger.RecordedError(log.CAKC105, issuedAt.UTC(), now.UTC(), skew)
+	}
+
+	if config.Issuer != "" {
+		issuer, _ := claims["iss"].(string)
+		if issuer != config.Issuer {
+			return logger.RecordedError(log.CAKC106, issuer, config.Issuer)
+		}
+	}
+
+	if config.Audience != "" {
+		audiences := audienceClaim(claims)
+		if !slices.Contains(audiences, config.Audience) {
+			return logger.RecordedError(log.CAKC107, audiences, config.Audience)
+		}
+	}
+
+	if checkIdentity && config.IdentityClaim != "" {
+		if _, ok := nestedClaim(claims, config.IdentityClaim); !ok {
+			return logger.RecordedError(log.CAKC108, config.IdentityClaim)
+		}
+	}
+
+	return nil
+}
+
+// audienceClaim returns the "aud" claim, which may be a single string or an
+// array of strings
+func audienceClaim(claims map[string]interface{}) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		audiences := make([]string, 0, len(aud))
+		for _, value := range aud {
+			if s, ok := value.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+		return audiences
+	default:
+		return nil
+	}
+}
+
+// nestedClaim looks up a claim by its path, using "/" to separate the names
+// of nested claims as Conjur does for token-app-property
+func nestedClaim(claims map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = claims
+	for _, name := range strings.Split(path, "/") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok || value == nil || value == "" {
+			return nil, false
+		}
+	}
+	return value, true
+}
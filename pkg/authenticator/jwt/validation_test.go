@@ -0,0 +1,120 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+func TestValidateClaims(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	config := ValidationConfig{ClockSkew: time.Minute}
+
+	testCases := []struct {
+		description   string
+		claims        map[string]interface{}
+		config        ValidationConfig
+		checkIdentity bool
+		expectedError string
+	}{
+		{
+			description: "valid token",
+			claims: map[string]interface{}{
+				"iss": "https://issuer.example.com",
+				"aud": []string{"conjur", "other"},
+				"sub": "system:serviceaccount:apps:my-sa",
+				"exp": now.Add(time.Hour).Unix(),
+				"nbf": now.Unix(),
+				"iat": now.Unix(),
+			},
+			config: ValidationConfig{
+				ClockSkew:     time.Minute,
+				Issuer:        "https://issuer.example.com",
+				Audience:      "conjur",
+				IdentityClaim: "sub",
+			},
+			checkIdentity: true,
+		},
+		{
+			description: "expired within the clock skew",
+			claims:      map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()},
+			config:      config,
+		},
+		{
+			description:   "expired",
+			claims:        map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()},
+			config:        config,
+			expectedError: "CAKC103 JWT expired at 2026-10-19 11:58:00 +0000 UTC",
+		},
+		{
+			description:   "not yet valid",
+			claims:        map[string]interface{}{"nbf": now.Add(5 * time.Minute).Unix()},
+			config:        config,
+			expectedError: "CAKC104 JWT is not valid before 2026-10-19 12:05:00 +0000 UTC",
+		},
+		{
+			description:   "issued in the future",
+			claims:        map[string]interface{}{"iat": now.Add(5 * time.Minute).Unix()},
+			config:        config,
+			expectedError: "CAKC105",
+		},
+		{
+			description:   "wrong issuer",
+			claims:        map[string]interface{}{"iss": "https://other.example.com"},
+			config:        ValidationConfig{Issuer: "https://issuer.example.com"},
+			expectedError: `CAKC106 JWT issuer "https://other.example.com" does not match the expected issuer "https://issuer.example.com"`,
+		},
+		{
+			description: "single audience string",
+			claims:      map[string]interface{}{"aud": "conjur"},
+			config:      ValidationConfig{Audience: "conjur"},
+		},
+		{
+			description:   "wrong audience",
+			claims:        map[string]interface{}{"aud": []string{"kubernetes"}},
+			config:        ValidationConfig{Audience: "conjur"},
+			expectedError: `CAKC107 JWT audience ["kubernetes"] does not include the expected audience "conjur"`,
+		},
+		{
+			description:   "missing identity claim",
+			claims:        map[string]interface{}{"sub": "my-app"},
+			config:        ValidationConfig{IdentityClaim: "workload"},
+			checkIdentity: true,
+			expectedError: `CAKC108 JWT does not contain the identity claim "workload"`,
+		},
+		{
+			description: "nested identity claim",
+			claims: map[string]interface{}{
+				"kubernetes.io": map[string]interface{}{"namespace": "apps"},
+			},
+			config:        ValidationConfig{IdentityClaim: "kubernetes.io/namespace"},
+			checkIdentity: true,
+		},
+		{
+			description: "identity claim is not needed when a login is configured",
+			claims:      map[string]interface{}{"sub": "my-app"},
+			config:      ValidationConfig{IdentityClaim: "workload"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateClaims(log.RequestLogger{}, unsignedJWT(tc.claims), tc.config, tc.checkIdentity, now)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			}
+		})
+	}
+
+	t.Run("malformed token", func(t *testing.T) {
+		err := validateClaims(log.RequestLogger{}, "not-a-jwt", config, false, now)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "CAKC109")
+	})
+}
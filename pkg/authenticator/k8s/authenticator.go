@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/rand"
@@ -9,16 +10,21 @@ import (
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fullsailor/pkcs7"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/access_token"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
@@ -33,11 +39,28 @@ var bufferTime = 30 * time.Second
 // Authenticator contains the configuration and client
 // for the authentication connection to Conjur
 type Authenticator struct {
-	client      *http.Client
-	privateKey  *rsa.PrivateKey
-	accessToken access_token.AccessToken
-	config      *Config
-	PublicCert  *x509.Certificate
+	client             *http.Client
+	caBundle           *common.CABundle
+	privateKey         crypto.Signer
+	signatureAlgorithm x509.SignatureAlgorithm
+	accessToken        access_token.AccessToken
+	config             *Config
+	PublicCert         *x509.Certificate
+	mtlsClient         atomic.Pointer[mtlsClient]
+
+	// certMutex guards PublicCert, which is replaced by background renewals
+	certMutex sync.RWMutex
+	// loginMutex serializes logins, which share the client certificate file
+	loginMutex sync.Mutex
+	renewal    renewal
+	certCache  *certCache
+}
+
+// mtlsClient is the HTTP client presenting a given client certificate. It is
+// built once per certificate so that its connections are reused.
+type mtlsClient struct {
+	cert   *x509.Certificate
+	client *http.Client
 }
 
 const (
@@ -49,22 +72,39 @@ const (
 
 // NewWithAccessToken creates a new authenticator instance from a given access token
 func NewWithAccessToken(config Config, accessToken access_token.AccessToken) (*Authenticator, error) {
-	signingKey, err := rsa.GenerateKey(rand.Reader, 4096)
+	cache, err := newCertCache(config)
 	if err != nil {
-		return nil, log.RecordedError(log.CAKC030, err)
+		return nil, err
 	}
 
-	client, err := common.NewHTTPSClient(config.Common.SSLCertificate, nil, nil)
+	caBundle, err := config.Common.CABundle()
+	if err != nil {
+		return nil, err
+	}
+	client, err := common.NewHTTPSClientWithCABundle(caBundle, config.Common.Transport, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Authenticator{
+	auth := &Authenticator{
 		client:      client,
-		privateKey:  signingKey,
+		caBundle:    caBundle,
 		accessToken: accessToken,
 		config:      &config,
-	}, nil
+		certCache:   cache,
+	}
+
+	// A restarted container reuses the cached key pair and certificate and
+	// does not need to log in
+	if auth.loadCachedCertificate() {
+		return auth, nil
+	}
+
+	auth.privateKey, auth.signatureAlgorithm, err = newSigner(config)
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
 }
 
 // GetAccessToken is getter for accessToken
@@ -72,6 +112,23 @@ func (auth *Authenticator) GetAccessToken() access_token.AccessToken {
 	return auth.accessToken
 }
 
+// Stop cancels the background renewal of the client certificate, waiting for
+// one in progress, releases the private key, e.g. its PKCS#11 session, and
+// closes the idle connections to Conjur. The authenticator must not be used
+// afterwards.
+func (auth *Authenticator) Stop() {
+	auth.stopRenewal()
+
+	auth.client.CloseIdleConnections()
+	if current := auth.mtlsClient.Load(); current != nil {
+		current.client.CloseIdleConnections()
+	}
+
+	if closer, ok := auth.privateKey.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
 // Authenticate sends Conjur an authenticate request and writes the response
 // to the token file (after decrypting it if needed). It also manages state of
 // certificates.
@@ -80,12 +137,17 @@ func (auth *Authenticator) Authenticate() error {
 	return auth.AuthenticateWithContext(context.TODO())
 }
 
+// AuthenticateWithContext is Authenticate with tracing. The requests of each
+// attempt carry a new request ID, which tags its log messages and its span.
 func (auth *Authenticator) AuthenticateWithContext(ctx context.Context) error {
-	log.Info(log.CAKC040, auth.config.Common.Username)
+	logger := log.WithRequestID(common.NewRequestID())
+	logger.Info(log.CAKC040, auth.config.Common.Username)
 
 	tr := trace.NewOtelTracer(otel.Tracer("conjur-authn-k8s-client"))
 	spanCtx, span := tr.Start(ctx, "Authenticate")
 	defer span.End()
+	span.SetAttributes(attribute.String(common.RequestIDAttribute, logger.RequestID()))
+	spanCtx = log.NewContext(spanCtx, logger)
 
 	err := auth.loginIfNeeded(spanCtx, tr)
 	if err != nil {
@@ -113,14 +175,13 @@ func (auth *Authenticator) AuthenticateWithContext(ctx context.Context) error {
 		return err
 	}
 
-	log.Info(log.CAKC035)
+	logger.Info(log.CAKC035)
 	return nil
 }
 
 // generateCSR prepares the CSR
 func (auth *Authenticator) generateCSR(commonName string) ([]byte, error) {
-	sanURIString, err := generateSANURI(auth.config.PodNamespace, auth.config.PodName)
-	sanURI, err := url.Parse(sanURIString)
+	sanURIs, sanIPs, err := subjectAltNames(*auth.config)
 	if err != nil {
 		return nil, err
 	}
@@ -131,12 +192,10 @@ func (auth *Authenticator) generateCSR(commonName string) ([]byte, error) {
 
 	template := x509.CertificateRequest{
 		Subject:            subj,
-		SignatureAlgorithm: x509.SHA256WithRSA,
+		SignatureAlgorithm: auth.signatureAlgorithm,
 	}
 
-	subjectAltNamesValue, err := marshalSANs(nil, nil, nil, []*url.URL{
-		sanURI,
-	})
+	subjectAltNamesValue, err := marshalSANs(nil, nil, sanIPs, sanURIs)
 	if err != nil {
 		return nil, err
 	}
@@ -154,21 +213,36 @@ func (auth *Authenticator) generateCSR(commonName string) ([]byte, error) {
 // login sends Conjur a CSR and verifies that the client cert is
 // successfully retrieved
 func (auth *Authenticator) login(ctx context.Context, tracer trace.Tracer) error {
+	auth.loginMutex.Lock()
+	defer auth.loginMutex.Unlock()
 
-	log.Debug(log.CAKC041, auth.config.Common.Username)
+	logger := log.FromContext(ctx)
+	logger.Debug(log.CAKC041, auth.config.Common.Username)
 
 	_, span := tracer.Start(ctx, "Generate CSR")
 	csrRawBytes, err := auth.generateCSR(auth.config.Common.Username.Suffix)
+	if err != nil {
+		span.RecordErrorAndSetStatus(err)
+		span.End()
+		return logger.RecordedError(log.CAKC112, err)
+	}
 
 	csrBytes := pem.EncodeToMemory(&pem.Block{
 		Type: "CERTIFICATE REQUEST", Bytes: csrRawBytes,
 	})
 	span.End()
 
-	req, err := LoginRequest(auth.config.Common.URL, csrBytes, auth.config.Common.Username.Prefix)
+	req, err := LoginRequestWithContext(ctx, auth.config.Common.URL, csrBytes, auth.config.Common.Username.Prefix)
 	if err != nil {
 		return err
 	}
+	common.SetRequestID(req, logger.RequestID())
+	common.SetHeaders(req, auth.config.Common.Transport.Headers)
+
+	// Remove the injection log of an earlier login, so that it is not taken
+	// for a failure of this one
+	injectCertLogPath := auth.injectCertLogPath()
+	os.Remove(injectCertLogPath)
 
 	_, span = tracer.Start(ctx, "Send login request")
 	resp, err := auth.client.Do(req)
@@ -176,31 +250,42 @@ func (auth *Authenticator) login(ctx context.Context, tracer trace.Tracer) error
 		logIPS(req.Host)
 		span.RecordErrorAndSetStatus(err)
 		span.End()
-		return log.RecordedError(log.CAKC028, err)
+		return logger.RecordedError(log.CAKC028, err)
 	}
 	span.End()
 
 	err = utils.ValidateResponse(resp)
 	if err != nil {
-		return log.RecordedError(log.CAKC029, err)
+		return logger.RecordedError(log.CAKC029, err)
+	}
+	resp.Body.Close()
+
+	// The response code was changed from 200 to 202 in the same Conjur version
+	// that started writing the cert injection logs to the client. Verifying that
+	// the response code is 202 will verify that we look for the log file only
+	// if we expect it to be there
+	if resp.StatusCode != 202 {
+		injectCertLogPath = ""
 	}
 
 	_, span = tracer.Start(ctx, "Wait for cert file")
 	// Ensure client certificate exists before attempting to read it, with a tolerance
-	// for small delays
-	err = utils.WaitForFile(
+	// for small delays. Stop waiting as soon as Conjur reports an injection error.
+	err = utils.WaitForFileOrLog(
+		ctx,
 		auth.config.Common.ClientCertPath,
+		injectCertLogPath,
 		auth.config.Common.ClientCertRetryCountLimit,
 	)
 	if err != nil {
-		// The response code was changed from 200 to 202 in the same Conjur version
-		// that started writing the cert injection logs to the client. Verifying that
-		// the response code is 202 will verify that we look for the log file only
-		// if we expect it to be there
-		if resp.StatusCode == 202 {
-			injectClientCertError := consumeInjectClientCertError(auth.config.InjectCertLogPath)
+		if injectCertLogPath != "" {
+			injectClientCertError := consumeInjectClientCertError(logger, injectCertLogPath)
 			if injectClientCertError != "" {
-				log.Error(log.CAKC055, injectClientCertError)
+				// Logged once with its classification, the returned error
+				// carries the description and hint
+				injectionErr := parseInjectionError(injectClientCertError)
+				logger.Error(log.CAKC055, injectionErr.Failure, injectClientCertError)
+				err = injectionErr
 			}
 		}
 		span.RecordErrorAndSetStatus(err)
@@ -217,46 +302,62 @@ func (auth *Authenticator) login(ctx context.Context, tracer trace.Tracer) error
 		span.End()
 
 		if os.IsNotExist(err) {
-			return log.RecordedError(log.CAKC011, auth.config.Common.ClientCertPath)
+			return logger.RecordedError(log.CAKC011, auth.config.Common.ClientCertPath)
 		}
 
-		return log.RecordedError(log.CAKC012, err)
+		return logger.RecordedError(log.CAKC012, err)
 	}
-	log.Debug(log.CAKC049, auth.config.Common.ClientCertPath)
+	logger.Debug(log.CAKC049, auth.config.Common.ClientCertPath)
 
 	certDERBlock, certPEMBlock := pem.Decode(certPEMBlock)
 	cert, err := x509.ParseCertificate(certDERBlock.Bytes)
 	if err != nil {
-		return log.RecordedError(log.CAKC013, auth.config.Common.ClientCertPath, err)
 		span.RecordErrorAndSetStatus(err)
 		span.End()
 
-		return log.RecordedError(log.CAKC013, auth.config.Common.ClientCertPath, err)
+		return logger.RecordedError(log.CAKC013, auth.config.Common.ClientCertPath, err)
 	}
 
-	auth.PublicCert = cert
+	auth.setCertificate(cert)
 	span.End()
 
 	// clean up the client cert so it's only available in memory
 	os.Remove(auth.config.Common.ClientCertPath)
-	log.Debug(log.CAKC050)
+	logger.Debug(log.CAKC050)
+
+	auth.saveCachedCertificate(logger, cert)
+
+	auth.scheduleRenewal(logger, cert)
 
 	return nil
 }
 
+// certificate returns the current client certificate
+func (auth *Authenticator) certificate() *x509.Certificate {
+	auth.certMutex.RLock()
+	defer auth.certMutex.RUnlock()
+	return auth.PublicCert
+}
+
+func (auth *Authenticator) setCertificate(cert *x509.Certificate) {
+	auth.certMutex.Lock()
+	defer auth.certMutex.Unlock()
+	auth.PublicCert = cert
+}
+
 // IsLoggedIn returns true if we are logged in (have a cert)
 func (auth *Authenticator) IsLoggedIn() bool {
-	return auth.PublicCert != nil
+	return auth.certificate() != nil
 }
 
 // isCertExpired returns true if certificate is expired or close to expiring
-func (auth *Authenticator) isCertExpired() bool {
-	certExpiresOn := auth.PublicCert.NotAfter.UTC()
+func (auth *Authenticator) isCertExpired(logger log.RequestLogger) bool {
+	certExpiresOn := auth.certificate().NotAfter.UTC()
 	currentDate := time.Now().UTC()
 
-	log.Debug(log.CAKC042, certExpiresOn)
-	log.Debug(log.CAKC043, currentDate)
-	log.Debug(log.CAKC044, bufferTime)
+	logger.Debug(log.CAKC042, certExpiresOn)
+	logger.Debug(log.CAKC043, currentDate)
+	logger.Debug(log.CAKC044, bufferTime)
 
 	return currentDate.Add(bufferTime).After(certExpiresOn)
 }
@@ -264,24 +365,32 @@ func (auth *Authenticator) isCertExpired() bool {
 // loginIfNeeded checks if we need to send a login request to Conjur and sends
 // one if needed
 func (auth *Authenticator) loginIfNeeded(ctx context.Context, tracer trace.Tracer) error {
+	logger := log.FromContext(ctx)
+
 	if !auth.IsLoggedIn() {
-		log.Debug(log.CAKC039)
+		logger.Debug(log.CAKC039)
 
 		if err := auth.login(ctx, tracer); err != nil {
-			return log.RecordedError(log.CAKC015)
+			// Injection failures are returned as is, so that callers can tell
+			// them apart from other login failures
+			var injectionErr *InjectionError
+			if errors.As(err, &injectionErr) {
+				return err
+			}
+			return logger.RecordedError(log.CAKC015)
 		}
 
-		log.Debug(log.CAKC036)
+		logger.Debug(log.CAKC036)
 	}
 
-	if auth.isCertExpired() {
-		log.Debug(log.CAKC038)
+	if auth.isCertExpired(logger) {
+		logger.Debug(log.CAKC038)
 
 		if err := auth.login(ctx, tracer); err != nil {
 			return err
 		}
 
-		log.Debug(log.CAKC037)
+		logger.Debug(log.CAKC037)
 	}
 
 	return nil
@@ -293,19 +402,16 @@ func (auth *Authenticator) loginIfNeeded(ctx context.Context, tracer trace.Trace
 func (auth *Authenticator) sendAuthenticationRequest(ctx context.Context, tracer trace.Tracer) ([]byte, error) {
 	_, span := tracer.Start(ctx, "Send authentication request")
 	defer span.End()
+	logger := log.FromContext(ctx)
 
-	privDer := x509.MarshalPKCS1PrivateKey(auth.privateKey)
-	keyPEMBlock := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privDer})
-
-	certPEMBlock := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: auth.PublicCert.Raw})
-
-	client, err := common.NewHTTPSClient(auth.config.Common.SSLCertificate, certPEMBlock, keyPEMBlock)
+	client, err := auth.mutualTLSClient(logger)
 	if err != nil {
 		span.RecordErrorAndSetStatus(err)
 		return nil, err
 	}
 
-	req, err := AuthenticateRequest(
+	req, err := AuthenticateRequestWithContext(
+		ctx,
 		auth.config.Common.URL,
 		auth.config.Common.Account,
 		auth.config.Common.Username.FullUsername,
@@ -314,12 +420,17 @@ func (auth *Authenticator) sendAuthenticationRequest(ctx context.Context, tracer
 		span.RecordErrorAndSetStatus(err)
 		return nil, err
 	}
+	if auth.canDecryptToken() {
+		acceptEncryptedToken(req)
+	}
+	common.SetRequestID(req, logger.RequestID())
+	common.SetHeaders(req, auth.config.Common.Transport.Headers)
 
-	log.Debug(log.CAKC069, AuthnType)
+	logger.Debug(log.CAKC069, AuthnType)
 	resp, err := client.Do(req)
 	if err != nil {
 		span.RecordErrorAndSetStatus(err)
-		return nil, log.RecordedError(log.CAKC027, err)
+		return nil, logger.RecordedError(log.CAKC027, err)
 	}
 
 	err = utils.ValidateResponse(resp)
@@ -331,21 +442,56 @@ func (auth *Authenticator) sendAuthenticationRequest(ctx context.Context, tracer
 	return utils.ReadResponseBody(resp)
 }
 
+// mutualTLSClient returns the HTTP client presenting the current client
+// certificate. A new client is built when login renews the certificate, and
+// the idle connections of the previous one are closed.
+func (auth *Authenticator) mutualTLSClient(logger log.RequestLogger) (*http.Client, error) {
+	cert := auth.certificate()
+	current := auth.mtlsClient.Load()
+	if current != nil && current.cert == cert {
+		return current.client, nil
+	}
+
+	client, err := common.NewHTTPSClientWithSigner(auth.caBundle, auth.config.Common.Transport, cert, auth.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug(log.CAKC120)
+	if previous := auth.mtlsClient.Swap(&mtlsClient{cert: cert, client: client}); previous != nil {
+		previous.client.CloseIdleConnections()
+	}
+	return client, nil
+}
+
 // parseAuthenticationResponse takes the response from the Authenticate
 // request, decrypts if needed, and returns it
 func (auth *Authenticator) parseAuthenticationResponse(ctx context.Context, tracer trace.Tracer, response []byte) ([]byte, error) {
 	_, span := tracer.Start(ctx, "Parse authentication response")
 	defer span.End()
 
-	return response, nil
-}
+	// Conjur returns the access token as JSON unless it encrypted it for the
+	// client certificate, in which case it is a PEM encoded PKCS#7 envelope
+	if !bytes.HasPrefix(bytes.TrimSpace(response), []byte("-----BEGIN ")) {
+		return response, nil
+	}
 
-// generateSANURI returns the formatted uri(SPIFFEE format for now) for the certificate.
-func generateSANURI(namespace, podname string) (string, error) {
-	if namespace == "" || podname == "" {
-		return "", log.RecordedError(log.CAKC008, namespace, podname)
+	logger := log.FromContext(ctx)
+	logger.Debug(log.CAKC124)
+	content, err := decodeFromPEM(logger, response, auth.certificate(), auth.privateKey)
+	if err != nil {
+		span.RecordErrorAndSetStatus(err)
+		return nil, err
 	}
-	return fmt.Sprintf("spiffe://cluster.local/namespace/%s/podname/%s", namespace, podname), nil
+	return content, nil
+}
+
+// canDecryptToken returns true if the private key can decrypt an access token
+// that Conjur encrypted for the client certificate. PKCS#7 envelopes are
+// decrypted with RSA keys held in memory only.
+func (auth *Authenticator) canDecryptToken() bool {
+	_, ok := auth.privateKey.(*rsa.PrivateKey)
+	return ok
 }
 
 func marshalSANs(dnsNames, emailAddresses []string, ipAddresses []net.IP, uris []*url.URL) ([]byte, error) {
@@ -370,41 +516,53 @@ func marshalSANs(dnsNames, emailAddresses []string, ipAddresses []net.IP, uris [
 	return asn1.Marshal(rawValues)
 }
 
-func decodeFromPEM(PEMBlock []byte, PublicCert *x509.Certificate, privateKey crypto.PrivateKey) ([]byte, error) {
+func decodeFromPEM(logger log.RequestLogger, PEMBlock []byte, PublicCert *x509.Certificate, privateKey crypto.PrivateKey) ([]byte, error) {
 	var decodedPEM []byte
 
 	tokenDerBlock, _ := pem.Decode(PEMBlock)
+	if tokenDerBlock == nil {
+		return nil, logger.RecordedError(log.CAKC025, "no PEM block found")
+	}
 	p7, err := pkcs7.Parse(tokenDerBlock.Bytes)
 	if err != nil {
-		return nil, log.RecordedError(log.CAKC026, err)
+		return nil, logger.RecordedError(log.CAKC026, err)
 	}
 
 	decodedPEM, err = p7.Decrypt(PublicCert, privateKey)
 	if err != nil {
-		return nil, log.RecordedError(log.CAKC025, err)
+		return nil, logger.RecordedError(log.CAKC025, err)
 	}
 
 	return decodedPEM, nil
 }
 
-func consumeInjectClientCertError(path string) string {
+// injectCertLogPath returns the path of the log in which Conjur reports
+// errors injecting the client certificate
+func (auth *Authenticator) injectCertLogPath() string {
+	if auth.config.InjectCertLogPath == "" {
+		return DefaultInjectCertLogPath
+	}
+	return auth.config.InjectCertLogPath
+}
+
+func consumeInjectClientCertError(logger log.RequestLogger, path string) string {
 	// The log file will not exist in old Conjur versions
 	err := utils.VerifyFileExists(path)
 	if err != nil {
-		log.Warn(log.CAKC056, path)
+		logger.Warn(log.CAKC056, path)
 		return ""
 	}
 
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
-		log.Error(log.CAKC053, path)
+		logger.Error(log.CAKC053, path)
 		return ""
 	}
 
-	log.Debug(log.CAKC057, path)
+	logger.Debug(log.CAKC057, path)
 	err = os.Remove(path)
 	if err != nil {
-		log.Error(log.CAKC054, path)
+		logger.Error(log.CAKC054, path)
 	}
 
 	return string(content)
@@ -0,0 +1,285 @@
+package k8s
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+const (
+	certCacheVersion    = 1
+	certCacheSaltSize   = 16
+	certCacheMinKeySize = 32
+	certCacheKeyInfo    = "conjur-authn-k8s-client client certificate cache"
+)
+
+// certCache stores the private key and client certificate in a file encrypted
+// with AES-256-GCM, so that a restarted container can reuse them instead of
+// logging in again. The encryption key is derived from the contents of the
+// cache key file with HKDF-SHA256 and a random salt stored in the cache file.
+type certCache struct {
+	path   string
+	secret []byte
+}
+
+// cachedCertificate is the plaintext of the cache file
+type cachedCertificate struct {
+	Identity     string `json:"identity"`
+	KeyAlgorithm string `json:"keyAlgorithm"`
+	PrivateKey   []byte `json:"privateKey"`
+	Certificate  []byte `json:"certificate"`
+}
+
+// newCertCache returns the configured certificate cache, or nil if the cache
+// is disabled
+func newCertCache(config Config) (*certCache, error) {
+	if config.CertCachePath == "" {
+		return nil, nil
+	}
+	if config.KeyProvider != "" && config.KeyProvider != KeyProviderMemory {
+		return nil, log.RecordedError(log.CAKC129, KeyProviderMemory)
+	}
+	if config.CertCacheKeyPath == "" {
+		return nil, log.RecordedError(log.CAKC009, "CONJUR_CLIENT_CERT_CACHE_KEY_PATH")
+	}
+
+	secret, err := os.ReadFile(config.CertCacheKeyPath)
+	if err != nil {
+		return nil, log.RecordedError(log.CAKC128, config.CertCacheKeyPath, err)
+	}
+	secret = bytes.TrimSpace(secret)
+	if len(secret) < certCacheMinKeySize {
+		return nil, log.RecordedError(
+			log.CAKC128,
+			config.CertCacheKeyPath,
+			fmt.Sprintf("the key must be at least %d bytes long", certCacheMinKeySize),
+		)
+	}
+
+	return &certCache{path: config.CertCachePath, secret: secret}, nil
+}
+
+// certCacheIdentity identifies the Conjur host and pod the cached certificate
+// was issued to
+func certCacheIdentity(config Config) (string, error) {
+	uris, _, err := subjectAltNames(config)
+	if err != nil {
+		return "", err
+	}
+
+	username := ""
+	if config.Common.Username != nil {
+		username = config.Common.Username.FullUsername
+	}
+	return strings.Join([]string{config.Common.URL, config.Common.Account, username, uris[0].String()}, " "), nil
+}
+
+// load returns the cached private key and certificate if the certificate is
+// still valid and was issued for the given identity and key algorithm. It
+// returns nil values without an error when nothing is cached yet.
+func (cache *certCache) load(identity, algorithm string) (crypto.Signer, *x509.Certificate, error) {
+	data, err := os.ReadFile(cache.path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := cache.decrypt(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cached cachedCertificate
+	if err := json.Unmarshal(plaintext, &cached); err != nil {
+		return nil, nil, err
+	}
+
+	if cached.Identity != identity {
+		return nil, nil, errors.New("the certificate was issued for a different identity")
+	}
+	if cached.KeyAlgorithm != algorithm {
+		return nil, nil, fmt.Errorf("the key algorithm is %s instead of %s", cached.KeyAlgorithm, algorithm)
+	}
+
+	cert, err := x509.ParseCertificate(cached.Certificate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !time.Now().Add(bufferTime).Before(cert.NotAfter) {
+		return nil, nil, fmt.Errorf("the certificate expires at %s", cert.NotAfter)
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(cached.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := parsedKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("the private key cannot sign")
+	}
+	if !publicKeysEqual(key.Public(), cert.PublicKey) {
+		return nil, nil, errors.New("the private key does not match the certificate")
+	}
+
+	return key, cert, nil
+}
+
+// save replaces the cache file with the given private key and certificate
+func (cache *certCache) save(identity, algorithm string, key crypto.Signer, cert *x509.Certificate) error {
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(cachedCertificate{
+		Identity:     identity,
+		KeyAlgorithm: algorithm,
+		PrivateKey:   privateKey,
+		Certificate:  cert.Raw,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cache.encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it, so that a restart never sees a
+	// partially written cache
+	tmp, err := os.CreateTemp(filepath.Dir(cache.path), filepath.Base(cache.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cache.path)
+}
+
+// encrypt returns the version and salt followed by the sealed plaintext. The
+// version and salt are authenticated as additional data.
+func (cache *certCache) encrypt(plaintext []byte) ([]byte, error) {
+	header := make([]byte, 1+certCacheSaltSize)
+	header[0] = certCacheVersion
+	if _, err := rand.Read(header[1:]); err != nil {
+		return nil, err
+	}
+
+	aead, err := cache.aead(header[1:])
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(header, nil, plaintext, header), nil
+}
+
+func (cache *certCache) decrypt(data []byte) ([]byte, error) {
+	if len(data) < 1+certCacheSaltSize || data[0] != certCacheVersion {
+		return nil, errors.New("unsupported cache format")
+	}
+	header, ciphertext := data[:1+certCacheSaltSize], data[1+certCacheSaltSize:]
+
+	aead, err := cache.aead(header[1:])
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nil, ciphertext, header)
+	if err != nil {
+		return nil, errors.New("the cache cannot be decrypted with the cache key")
+	}
+	return plaintext, nil
+}
+
+func (cache *certCache) aead(salt []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, cache.secret, salt, certCacheKeyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithRandomNonce(block)
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// loadCachedCertificate uses the cached private key and certificate, if they
+// are still valid, and returns true if it did
+func (auth *Authenticator) loadCachedCertificate() bool {
+	if auth.certCache == nil {
+		return false
+	}
+
+	// An invalid algorithm is reported when the key pair is generated instead
+	name, algorithm, err := lookupKeyAlgorithm(*auth.config)
+	if err != nil {
+		return false
+	}
+	identity, err := certCacheIdentity(*auth.config)
+	if err != nil {
+		log.Warn(log.CAKC126, auth.certCache.path, err)
+		return false
+	}
+
+	key, cert, err := auth.certCache.load(identity, name)
+	if err != nil {
+		log.Warn(log.CAKC126, auth.certCache.path, err)
+		return false
+	}
+	if key == nil {
+		return false
+	}
+
+	auth.privateKey = key
+	auth.signatureAlgorithm = algorithm.signatureAlgorithm
+	auth.setCertificate(cert)
+	log.Info(log.CAKC125, auth.certCache.path, cert.NotAfter)
+
+	auth.scheduleRenewal(log.RequestLogger{}, cert)
+	return true
+}
+
+// saveCachedCertificate stores the private key and a newly issued certificate
+// in the cache. Failures only mean the next restart logs in again.
+func (auth *Authenticator) saveCachedCertificate(logger log.RequestLogger, cert *x509.Certificate) {
+	if auth.certCache == nil {
+		return
+	}
+
+	name, _, err := lookupKeyAlgorithm(*auth.config)
+	if err == nil {
+		var identity string
+		identity, err = certCacheIdentity(*auth.config)
+		if err == nil {
+			err = auth.certCache.save(identity, name, auth.privateKey, cert)
+		}
+	}
+	if err != nil {
+		logger.Warn(log.CAKC127, auth.certCache.path, err)
+	}
+}
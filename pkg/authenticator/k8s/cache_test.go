@@ -0,0 +1,206 @@
+package k8s
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newCacheTestCertificate returns a key pair and a self-signed certificate
+// for it, valid for the given lifetime
+func newCacheTestCertificate(t *testing.T, lifetime time.Duration) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-user"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(lifetime),
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+func newTestCertCache(t *testing.T, dir, secret string) *certCache {
+	keyPath := filepath.Join(dir, "cache-key")
+	assert.NoError(t, os.WriteFile(keyPath, []byte(secret+"\n"), 0600))
+
+	cache, err := newCertCache(Config{
+		CertCachePath:    filepath.Join(dir, "client-cert.cache"),
+		CertCacheKeyPath: keyPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func TestCertCache(t *testing.T) {
+	secret := strings.Repeat("s", certCacheMinKeySize)
+	identity := "https://conjur account host/test-user spiffe://cluster.local/namespace/ns/podname/pod"
+
+	t.Run("round trip", func(t *testing.T) {
+		cache := newTestCertCache(t, t.TempDir(), secret)
+		key, cert := newCacheTestCertificate(t, time.Hour)
+		assert.NoError(t, cache.save(identity, KeyAlgorithmECDSAP256, key, cert))
+
+		info, err := os.Stat(cache.path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+
+		loadedKey, loadedCert, err := cache.load(identity, KeyAlgorithmECDSAP256)
+		assert.NoError(t, err)
+		assert.True(t, key.Equal(loadedKey))
+		assert.True(t, cert.Equal(loadedCert))
+	})
+
+	t.Run("nothing cached", func(t *testing.T) {
+		cache := newTestCertCache(t, t.TempDir(), secret)
+
+		key, cert, err := cache.load(identity, KeyAlgorithmECDSAP256)
+		assert.NoError(t, err)
+		assert.Nil(t, key)
+		assert.Nil(t, cert)
+	})
+
+	testCases := []struct {
+		description string
+		lifetime    time.Duration
+		modify      func(t *testing.T, cache *certCache)
+		identity    string
+		algorithm   string
+		err         string
+	}{
+		{
+			description: "different identity",
+			lifetime:    time.Hour,
+			identity:    strings.Replace(identity, "podname/pod", "podname/other-pod", 1),
+			algorithm:   KeyAlgorithmECDSAP256,
+			err:         "the certificate was issued for a different identity",
+		},
+		{
+			description: "different key algorithm",
+			lifetime:    time.Hour,
+			identity:    identity,
+			algorithm:   KeyAlgorithmRSA4096,
+			err:         "the key algorithm is ecdsa-p256 instead of rsa-4096",
+		},
+		{
+			description: "certificate about to expire",
+			lifetime:    10 * time.Second,
+			identity:    identity,
+			algorithm:   KeyAlgorithmECDSAP256,
+			err:         "the certificate expires at",
+		},
+		{
+			description: "different cache key",
+			lifetime:    time.Hour,
+			modify: func(t *testing.T, cache *certCache) {
+				cache.secret = []byte(strings.Repeat("x", certCacheMinKeySize))
+			},
+			identity:  identity,
+			algorithm: KeyAlgorithmECDSAP256,
+			err:       "the cache cannot be decrypted with the cache key",
+		},
+		{
+			description: "tampered cache",
+			lifetime:    time.Hour,
+			modify: func(t *testing.T, cache *certCache) {
+				data, _ := os.ReadFile(cache.path)
+				data[len(data)-1] ^= 0xff
+				assert.NoError(t, os.WriteFile(cache.path, data, 0600))
+			},
+			identity:  identity,
+			algorithm: KeyAlgorithmECDSAP256,
+			err:       "the cache cannot be decrypted with the cache key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cache := newTestCertCache(t, t.TempDir(), secret)
+			key, cert := newCacheTestCertificate(t, tc.lifetime)
+			assert.NoError(t, cache.save(identity, KeyAlgorithmECDSAP256, key, cert))
+			if tc.modify != nil {
+				tc.modify(t, cache)
+			}
+
+			loadedKey, loadedCert, err := cache.load(tc.identity, tc.algorithm)
+			assert.ErrorContains(t, err, tc.err)
+			assert.Nil(t, loadedKey)
+			assert.Nil(t, loadedCert)
+		})
+	}
+}
+
+func TestNewCertCache(t *testing.T) {
+	dir := t.TempDir()
+	shortKeyPath := filepath.Join(dir, "short-key")
+	assert.NoError(t, os.WriteFile(shortKeyPath, []byte("too short"), 0600))
+
+	testCases := []struct {
+		description string
+		config      Config
+		err         string
+	}{
+		{
+			description: "disabled",
+			config:      Config{},
+		},
+		{
+			description: "missing key path",
+			config:      Config{CertCachePath: filepath.Join(dir, "cache")},
+			err:         "CAKC009 Environment variable 'CONJUR_CLIENT_CERT_CACHE_KEY_PATH' must be provided",
+		},
+		{
+			description: "key too short",
+			config: Config{
+				CertCachePath:    filepath.Join(dir, "cache"),
+				CertCacheKeyPath: shortKeyPath,
+			},
+			err: "CAKC128 Failed to read the client certificate cache key from " + shortKeyPath +
+				". Reason: the key must be at least 32 bytes long",
+		},
+		{
+			description: "PKCS#11 key provider",
+			config: Config{
+				CertCachePath:    filepath.Join(dir, "cache"),
+				CertCacheKeyPath: shortKeyPath,
+				KeyProvider:      KeyProviderPKCS11,
+			},
+			err: "CAKC129 The client certificate cache requires the memory key provider",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cache, err := newCertCache(tc.config)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				assert.Nil(t, cache)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package k8s
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
@@ -13,89 +12,80 @@ import (
 type Config struct {
 	Common            common.Config
 	InjectCertLogPath string
-	PodName           string
-	PodNamespace      string
+	PodName           string `setting:"MY_POD_NAME"`
+	PodNamespace      string `setting:"MY_POD_NAMESPACE"`
+	// PodUID, PodServiceAccount and PodIP are added to the login CSR as
+	// additional SANs when set
+	PodUID            string `setting:"MY_POD_UID"`
+	PodServiceAccount string `setting:"MY_POD_SERVICE_ACCOUNT"`
+	PodIP             string `setting:"MY_POD_IP"`
+	SPIFFETrustDomain string `setting:"CONJUR_SPIFFE_TRUST_DOMAIN"`
+	KeyAlgorithm      string `setting:"CONJUR_CLIENT_KEY_ALGORITHM"`
+	KeyProvider       string `setting:"CONJUR_CLIENT_KEY_PROVIDER"`
+	PKCS11            PKCS11Config
+	// RenewalFraction is the fraction of the client certificate lifetime after
+	// which it is renewed in the background, 0 disables background renewal
+	RenewalFraction float64 `setting:"CONJUR_CLIENT_CERT_RENEWAL_FRACTION"`
+	// CertCachePath is the file in which the private key and client certificate
+	// are cached, encrypted with the key read from CertCacheKeyPath. The cache
+	// is disabled if it is empty.
+	CertCachePath    string `setting:"CONJUR_CLIENT_CERT_CACHE_PATH"`
+	CertCacheKeyPath string `setting:"CONJUR_CLIENT_CERT_CACHE_KEY_PATH"`
 }
 
-// Default settings (this comment added to satisfy linter)
-const (
-	DefaultClientCertPath    = "/etc/conjur/ssl/client.pem"
-	DefaultInjectCertLogPath = "/tmp/conjur_copy_text_output.log"
-	DefaultTokenFilePath     = "/run/conjur/access-token"
-
-	// DefaultTokenRefreshTimeout is the default time the system waits to reauthenticate on error
-	DefaultTokenRefreshTimeout = "6m0s"
-
-	// DefaultClientCertRetryCountLimit is the amount of times we wait after successful
-	// login for the client certificate file to exist, where each time we wait for a second.
-	DefaultClientCertRetryCountLimit = "10"
-	AuthnType                        = "authn-k8s"
-)
-
-var requiredEnvVariables = []string{
-	"CONJUR_AUTHN_URL",
-	"CONJUR_ACCOUNT",
-	"CONJUR_AUTHN_LOGIN",
-	"MY_POD_NAMESPACE",
-	"MY_POD_NAME",
-}
-
-var envVariables = []string{
-	"CONJUR_ACCOUNT",
-	"CONJUR_AUTHN_LOGIN",
-	"CONJUR_AUTHN_TOKEN_FILE",
-	"CONJUR_AUTHN_URL",
-	"CONJUR_CERT_FILE",
-	"CONJUR_CLIENT_CERT_PATH",
-	"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT",
-	"CONJUR_SSL_CERTIFICATE",
-	"CONJUR_TOKEN_TIMEOUT",
-	"CONTAINER_MODE",
-	"DEBUG",
-	"LOG_LEVEL",
-	"MY_POD_NAME",
-	"MY_POD_NAMESPACE",
-}
-
-var defaultValues = map[string]string{
-	"CONJUR_CLIENT_CERT_PATH":              DefaultClientCertPath,
-	"CONJUR_AUTHN_TOKEN_FILE":              DefaultTokenFilePath,
-	"CONJUR_TOKEN_TIMEOUT":                 DefaultTokenRefreshTimeout,
-	"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT": DefaultClientCertRetryCountLimit,
+// PKCS11Config selects the PKCS#11 token in which the "pkcs11" key provider
+// generates the private key
+type PKCS11Config struct {
+	ModulePath string `setting:"CONJUR_PKCS11_MODULE"`
+	TokenLabel string `setting:"CONJUR_PKCS11_TOKEN_LABEL"`
+	PINPath    string `setting:"CONJUR_PKCS11_PIN_PATH"`
 }
 
-func durationFromString(key, value string) (time.Duration, error) {
-	duration, err := time.ParseDuration(value)
-	if err != nil {
-		return 0, fmt.Errorf(log.CAKC060, key, value)
+func (config PKCS11Config) validate() error {
+	required := []struct{ name, value string }{
+		{"CONJUR_PKCS11_MODULE", config.ModulePath},
+		{"CONJUR_PKCS11_TOKEN_LABEL", config.TokenLabel},
+		{"CONJUR_PKCS11_PIN_PATH", config.PINPath},
 	}
-	return duration, nil
+	for _, setting := range required {
+		if setting.value == "" {
+			return log.RecordedError(log.CAKC009, setting.name)
+		}
+	}
+	return nil
 }
 
-func (config *Config) LoadConfig(settings map[string]string) {
-	config.Common = common.Config{}
-	config.Common.LoadConfig(settings)
+// Default settings, defined in the common settings registry
+const (
+	DefaultClientCertPath            = common.DefaultClientCertPath
+	DefaultInjectCertLogPath         = "/tmp/conjur_copy_text_output.log"
+	DefaultTokenFilePath             = common.DefaultTokenFilePath
+	DefaultTokenRefreshTimeout       = common.DefaultTokenRefreshTimeout
+	DefaultClientCertRetryCountLimit = common.DefaultClientCertRetryCountLimit
+	DefaultKeyAlgorithm              = common.DefaultKeyAlgorithm
+	DefaultKeyProvider               = common.DefaultKeyProvider
+	DefaultSPIFFETrustDomain         = common.DefaultSPIFFETrustDomain
+	DefaultClientCertRenewalFraction = common.DefaultClientCertRenewalFraction
+
+	AuthnType = common.AuthnTypeK8s
+)
 
-	for key, value := range settings {
-		switch key {
-		case "MY_POD_NAME":
-			config.PodName = value
-		case "MY_POD_NAMESPACE":
-			config.PodNamespace = value
-		}
-	}
+// LoadConfig sets the configuration from the settings of the authenticator
+func (config *Config) LoadConfig(settings map[string]string) error {
+	config.Common = common.Config{}
+	return common.LoadSettings(config, settings)
 }
 
 func (config *Config) GetEnvVariables() []string {
-	return envVariables
+	return common.SettingNames(AuthnType)
 }
 
 func (config *Config) GetRequiredVariables() []string {
-	return requiredEnvVariables
+	return common.RequiredSettingNames(AuthnType)
 }
 
 func (config *Config) GetDefaultValues() map[string]string {
-	return defaultValues
+	return common.DefaultSettingValues(AuthnType)
 }
 
 func (config *Config) GetContainerMode() string {
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// InjectionFailure classifies why Conjur failed to inject the client
+// certificate into the authenticator container
+type InjectionFailure string
+
+// Injection failures recognized in the cert injection log
+const (
+	InjectionFailureExecForbidden      InjectionFailure = "exec-forbidden"
+	InjectionFailureContainerNotFound  InjectionFailure = "container-not-found"
+	InjectionFailureTarMissing         InjectionFailure = "tar-missing"
+	InjectionFailureReadOnlyFilesystem InjectionFailure = "read-only-filesystem"
+	InjectionFailureUnknown            InjectionFailure = "unknown"
+)
+
+// InjectionError is returned by authentication when Conjur accepted the login
+// request but failed to inject the client certificate, as opposed to failures
+// to reach Conjur or to authenticate. Use errors.As to tell them apart.
+type InjectionError struct {
+	Failure InjectionFailure
+	// Description and Hint explain the failure and how to fix it
+	Description string
+	Hint        string
+	// Log is the raw content of the cert injection log
+	Log string
+}
+
+func (err *InjectionError) Error() string {
+	return fmt.Sprintf(log.CAKC132, err.Description, err.Hint)
+}
+
+// injectionFailures are matched in order against the cert injection log, the
+// first match wins
+var injectionFailures = []struct {
+	failure     InjectionFailure
+	pattern     *regexp.Regexp
+	description string
+	hint        string
+}{
+	{
+		failure:     InjectionFailureExecForbidden,
+		pattern:     regexp.MustCompile(`(?i)pods/exec|cannot create resource "pods/exec"|exec.*forbidden|forbidden.*exec`),
+		description: "Conjur is not allowed to exec into the pod",
+		hint:        `Grant the Conjur service account the "create" verb on "pods/exec" in the application namespace`,
+	},
+	{
+		failure:     InjectionFailureContainerNotFound,
+		pattern:     regexp.MustCompile(`(?i)container (\S+ )?(is )?not (found|valid)|no such container`),
+		description: "Conjur did not find the authenticator container in the pod",
+		hint: "Name the container running the authenticator as set in the " +
+			`"authn-k8s/authentication-container-name" annotation of the Conjur host, "authenticator" by default`,
+	},
+	{
+		failure:     InjectionFailureTarMissing,
+		pattern:     regexp.MustCompile(`(?i)tar: (not found|command not found|no such file)|"tar": executable file not found`),
+		description: "the authenticator container has no tar command",
+		hint:        "Conjur copies the certificate with tar, use an authenticator image that includes it",
+	},
+	{
+		failure:     InjectionFailureReadOnlyFilesystem,
+		pattern:     regexp.MustCompile(`(?i)read-only file ?system`),
+		description: "the client certificate directory is read-only",
+		hint:        "Mount a writable volume, e.g. an emptyDir, on the directory of CONJUR_CLIENT_CERT_PATH",
+	},
+}
+
+// parseInjectionError maps the content of the cert injection log to an
+// InjectionError
+func parseInjectionError(content string) *InjectionError {
+	for _, known := range injectionFailures {
+		if known.pattern.MatchString(content) {
+			return &InjectionError{
+				Failure:     known.failure,
+				Description: known.description,
+				Hint:        known.hint,
+				Log:         content,
+			}
+		}
+	}
+
+	return &InjectionError{
+		Failure:     InjectionFailureUnknown,
+		Description: "see the cert injection log",
+		Hint:        "Check the Conjur server logs for the authn-k8s injection error",
+		Log:         content,
+	}
+}
@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInjectionError(t *testing.T) {
+	testCases := []struct {
+		description string
+		log         string
+		failure     InjectionFailure
+	}{
+		{
+			description: "exec forbidden",
+			log: `pods "app-7d9f8" is forbidden: User "system:serviceaccount:conjur:conjur-oss" ` +
+				`cannot create resource "pods/exec" in API group "" in the namespace "apps"`,
+			failure: InjectionFailureExecForbidden,
+		},
+		{
+			description: "container not found",
+			log:         `container authenticator is not valid for pod app-7d9f8`,
+			failure:     InjectionFailureContainerNotFound,
+		},
+		{
+			description: "named container not found",
+			log:         `Error from server: container "authenticator" not found in pod "app-7d9f8"`,
+			failure:     InjectionFailureContainerNotFound,
+		},
+		{
+			description: "tar missing",
+			log:         `OCI runtime exec failed: exec: "tar": executable file not found in $PATH: unknown`,
+			failure:     InjectionFailureTarMissing,
+		},
+		{
+			description: "tar missing from the shell",
+			log:         "sh: tar: not found\n",
+			failure:     InjectionFailureTarMissing,
+		},
+		{
+			description: "read-only filesystem",
+			log:         "sh: can't create /etc/conjur/ssl/client.pem: Read-only file system\n",
+			failure:     InjectionFailureReadOnlyFilesystem,
+		},
+		{
+			description: "unknown",
+			log:         "error writing csr file\n",
+			failure:     InjectionFailureUnknown,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := parseInjectionError(tc.log)
+
+			assert.Equal(t, tc.failure, err.Failure)
+			assert.Equal(t, tc.log, err.Log)
+			assert.NotEmpty(t, err.Hint)
+			assert.Contains(t, err.Error(), "CAKC132 Conjur failed to inject the client certificate, ")
+		})
+	}
+}
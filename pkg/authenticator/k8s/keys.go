@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// Key algorithms accepted in CONJUR_CLIENT_KEY_ALGORITHM
+const (
+	KeyAlgorithmRSA2048   = common.KeyAlgorithmRSA2048
+	KeyAlgorithmRSA3072   = common.KeyAlgorithmRSA3072
+	KeyAlgorithmRSA4096   = common.KeyAlgorithmRSA4096
+	KeyAlgorithmECDSAP256 = common.KeyAlgorithmECDSAP256
+	KeyAlgorithmECDSAP384 = common.KeyAlgorithmECDSAP384
+)
+
+// Key providers accepted in CONJUR_CLIENT_KEY_PROVIDER
+const (
+	KeyProviderMemory = common.KeyProviderMemory
+	KeyProviderPKCS11 = common.KeyProviderPKCS11
+)
+
+// keyAlgorithm describes how to generate a private key and sign a CSR with it
+type keyAlgorithm struct {
+	generate           func() (crypto.Signer, error)
+	signatureAlgorithm x509.SignatureAlgorithm
+}
+
+// keyAlgorithms are all approved by FIPS 186-5, so that every one of them can
+// be used in FIPS mode. Algorithms that are not must not be added.
+var keyAlgorithms = map[string]keyAlgorithm{
+	KeyAlgorithmRSA2048:   {generateRSA(2048), x509.SHA256WithRSA},
+	KeyAlgorithmRSA3072:   {generateRSA(3072), x509.SHA256WithRSA},
+	KeyAlgorithmRSA4096:   {generateRSA(4096), x509.SHA256WithRSA},
+	KeyAlgorithmECDSAP256: {generateECDSA(elliptic.P256()), x509.ECDSAWithSHA256},
+	KeyAlgorithmECDSAP384: {generateECDSA(elliptic.P384()), x509.ECDSAWithSHA384},
+}
+
+func generateRSA(bits int) func() (crypto.Signer, error) {
+	return func() (crypto.Signer, error) {
+		return rsa.GenerateKey(rand.Reader, bits)
+	}
+}
+
+func generateECDSA(curve elliptic.Curve) func() (crypto.Signer, error) {
+	return func() (crypto.Signer, error) {
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	}
+}
+
+// newSigner creates the key pair whose public key is sent to Conjur in the
+// login CSR, using the configured key provider. It returns a signer for the
+// private key, which is used for the CSR and the mTLS handshake without ever
+// being exported, along with the CSR signature algorithm to use with it.
+func newSigner(config Config) (crypto.Signer, x509.SignatureAlgorithm, error) {
+	name, algorithm, err := lookupKeyAlgorithm(config)
+	if err != nil {
+		return nil, x509.UnknownSignatureAlgorithm, err
+	}
+
+	switch config.KeyProvider {
+	case "", KeyProviderMemory:
+		log.Debug(log.CAKC111, name)
+		key, err := algorithm.generate()
+		if err != nil {
+			return nil, x509.UnknownSignatureAlgorithm, log.RecordedError(log.CAKC030, err)
+		}
+		return key, algorithm.signatureAlgorithm, nil
+	case KeyProviderPKCS11:
+		if err := config.PKCS11.validate(); err != nil {
+			return nil, x509.UnknownSignatureAlgorithm, err
+		}
+		key, err := newPKCS11Signer(config.PKCS11, name)
+		if err != nil {
+			return nil, x509.UnknownSignatureAlgorithm, err
+		}
+		return key, algorithm.signatureAlgorithm, nil
+	default:
+		return nil, x509.UnknownSignatureAlgorithm, log.RecordedError(log.CAKC060, "CONJUR_CLIENT_KEY_PROVIDER", config.KeyProvider)
+	}
+}
+
+// lookupKeyAlgorithm returns the configured key algorithm
+func lookupKeyAlgorithm(config Config) (string, keyAlgorithm, error) {
+	name := config.KeyAlgorithm
+	if name == "" {
+		name = DefaultKeyAlgorithm
+	}
+
+	algorithm, ok := keyAlgorithms[name]
+	if !ok {
+		return name, keyAlgorithm{}, log.RecordedError(log.CAKC060, "CONJUR_CLIENT_KEY_ALGORITHM", name)
+	}
+	return name, algorithm, nil
+}
@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertSigns checks that the signer produces signatures that verify against
+// its public key, by creating a self-signed certificate with it
+func assertSigns(t *testing.T, key crypto.Signer, signatureAlgorithm x509.SignatureAlgorithm) {
+	template := &x509.Certificate{
+		SerialNumber:       big.NewInt(1),
+		NotBefore:          time.Now(),
+		NotAfter:           time.Now().Add(time.Hour),
+		SignatureAlgorithm: signatureAlgorithm,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if !assert.NoError(t, err) {
+		return
+	}
+	cert, err := x509.ParseCertificate(der)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature))
+}
+
+func TestNewSigner(t *testing.T) {
+	testCases := []struct {
+		algorithm          string
+		signatureAlgorithm x509.SignatureAlgorithm
+		assertKey          func(t *testing.T, key crypto.Signer)
+	}{
+		{
+			algorithm:          KeyAlgorithmRSA2048,
+			signatureAlgorithm: x509.SHA256WithRSA,
+			assertKey: func(t *testing.T, key crypto.Signer) {
+				assert.Equal(t, 2048, key.(*rsa.PrivateKey).N.BitLen())
+			},
+		},
+		{
+			algorithm:          KeyAlgorithmRSA3072,
+			signatureAlgorithm: x509.SHA256WithRSA,
+			assertKey: func(t *testing.T, key crypto.Signer) {
+				assert.Equal(t, 3072, key.(*rsa.PrivateKey).N.BitLen())
+			},
+		},
+		{
+			algorithm:          KeyAlgorithmECDSAP256,
+			signatureAlgorithm: x509.ECDSAWithSHA256,
+			assertKey: func(t *testing.T, key crypto.Signer) {
+				assert.Equal(t, "P-256", key.(*ecdsa.PrivateKey).Curve.Params().Name)
+			},
+		},
+		{
+			algorithm:          KeyAlgorithmECDSAP384,
+			signatureAlgorithm: x509.ECDSAWithSHA384,
+			assertKey: func(t *testing.T, key crypto.Signer) {
+				assert.Equal(t, "P-384", key.(*ecdsa.PrivateKey).Curve.Params().Name)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			key, signatureAlgorithm, err := newSigner(Config{KeyAlgorithm: tc.algorithm})
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.signatureAlgorithm, signatureAlgorithm)
+			tc.assertKey(t, key)
+			assertSigns(t, key, signatureAlgorithm)
+		})
+	}
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		_, _, err := newSigner(Config{KeyAlgorithm: "dsa-1024"})
+		assert.EqualError(t, err, "CAKC060 Setting CONJUR_CLIENT_KEY_ALGORITHM given invalid value dsa-1024")
+	})
+
+	t.Run("unknown key provider", func(t *testing.T) {
+		_, _, err := newSigner(Config{KeyProvider: "vault"})
+		assert.EqualError(t, err, "CAKC060 Setting CONJUR_CLIENT_KEY_PROVIDER given invalid value vault")
+	})
+
+	t.Run("PKCS#11 settings missing", func(t *testing.T) {
+		_, _, err := newSigner(Config{
+			KeyProvider: KeyProviderPKCS11,
+			PKCS11:      PKCS11Config{ModulePath: "/usr/lib/softhsm/libsofthsm2.so"},
+		})
+		assert.EqualError(t, err, "CAKC009 Environment variable 'CONJUR_PKCS11_TOKEN_LABEL' must be provided")
+	})
+}
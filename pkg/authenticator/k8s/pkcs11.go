@@ -0,0 +1,371 @@
+//go:build cgo
+
+package k8s
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// pkcs11KeyLabel labels the keys generated by the client in the token
+const pkcs11KeyLabel = "conjur-authn-k8s-client"
+
+// pkcs11Signer is a crypto.Signer backed by a private key generated inside a
+// PKCS#11 token. The key is a sensitive, non-extractable session object: it
+// never leaves the token and is destroyed when the session ends.
+type pkcs11Signer struct {
+	ctx        *pkcs11.Ctx
+	modulePath string
+	session    pkcs11.SessionHandle
+	privateKey pkcs11.ObjectHandle
+	public     crypto.PublicKey
+	mutex      sync.Mutex
+	closed     bool
+}
+
+// pkcs11Modules counts the signers using each PKCS#11 module. The module state
+// is global to the process, so it is finalized when its last signer is closed.
+var pkcs11Modules = struct {
+	sync.Mutex
+	signers map[string]int
+}{signers: map[string]int{}}
+
+var pkcs11Curves = map[string]struct {
+	curve elliptic.Curve
+	oid   asn1.ObjectIdentifier
+}{
+	KeyAlgorithmECDSAP256: {elliptic.P256(), asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}},
+	KeyAlgorithmECDSAP384: {elliptic.P384(), asn1.ObjectIdentifier{1, 3, 132, 0, 34}},
+}
+
+var pkcs11RSABits = map[string]int{
+	KeyAlgorithmRSA2048: 2048,
+	KeyAlgorithmRSA3072: 3072,
+	KeyAlgorithmRSA4096: 4096,
+}
+
+// DigestInfo prefixes prepended to the digest for CKM_RSA_PKCS, see RFC 8017
+var pkcs1DigestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA1:   {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// Hash and MGF1 mechanisms used with CKM_RSA_PKCS_PSS
+var pssMechanisms = map[crypto.Hash]struct{ hash, mgf uint }{
+	crypto.SHA256: {pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256},
+	crypto.SHA384: {pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384},
+	crypto.SHA512: {pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512},
+}
+
+// newPKCS11Signer generates a key pair of the given algorithm in the token
+// selected by the configuration and returns a signer using its private key
+func newPKCS11Signer(config PKCS11Config, algorithm string) (crypto.Signer, error) {
+	mechanism, publicTemplate, privateTemplate, err := pkcs11KeyTemplates(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	pin, err := os.ReadFile(config.PINPath)
+	if err != nil {
+		return nil, log.RecordedError(log.CAKC116, config.PINPath, err)
+	}
+
+	ctx, err := openPKCS11Module(config.ModulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := openPKCS11Session(ctx, config.TokenLabel, strings.TrimSpace(string(pin)))
+	if err != nil {
+		closePKCS11Module(ctx, config.ModulePath)
+		return nil, err
+	}
+
+	log.Debug(log.CAKC118, algorithm, config.TokenLabel)
+	signer, err := generatePKCS11Key(ctx, session, algorithm, mechanism, publicTemplate, privateTemplate)
+	if err != nil {
+		ctx.CloseSession(session)
+		closePKCS11Module(ctx, config.ModulePath)
+		return nil, log.RecordedError(log.CAKC117, algorithm, config.TokenLabel, err)
+	}
+	signer.modulePath = config.ModulePath
+
+	return signer, nil
+}
+
+// openPKCS11Module loads and initializes the PKCS#11 module, which must be
+// released with closePKCS11Module
+func openPKCS11Module(modulePath string) (*pkcs11.Ctx, error) {
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		return nil, log.RecordedError(log.CAKC114, modulePath, "the module could not be loaded")
+	}
+
+	pkcs11Modules.Lock()
+	defer pkcs11Modules.Unlock()
+
+	err := ctx.Initialize()
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		ctx.Destroy()
+		return nil, log.RecordedError(log.CAKC114, modulePath, err)
+	}
+	pkcs11Modules.signers[modulePath]++
+	return ctx, nil
+}
+
+// closePKCS11Module releases a module opened with openPKCS11Module
+func closePKCS11Module(ctx *pkcs11.Ctx, modulePath string) {
+	pkcs11Modules.Lock()
+	defer pkcs11Modules.Unlock()
+
+	pkcs11Modules.signers[modulePath]--
+	if pkcs11Modules.signers[modulePath] <= 0 {
+		delete(pkcs11Modules.signers, modulePath)
+		ctx.Finalize()
+	}
+	ctx.Destroy()
+}
+
+// openPKCS11Session logs in to the token with the given label
+func openPKCS11Session(ctx *pkcs11.Ctx, tokenLabel, pin string) (pkcs11.SessionHandle, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, log.RecordedError(log.CAKC115, tokenLabel)
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil || info.Label != tokenLabel {
+			continue
+		}
+
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err != nil {
+			return 0, log.RecordedError(log.CAKC119, tokenLabel, err)
+		}
+
+		err = ctx.Login(session, pkcs11.CKU_USER, pin)
+		if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			ctx.CloseSession(session)
+			return 0, log.RecordedError(log.CAKC119, tokenLabel, err)
+		}
+
+		return session, nil
+	}
+
+	return 0, log.RecordedError(log.CAKC115, tokenLabel)
+}
+
+// pkcs11KeyTemplates returns the mechanism and attribute templates used to
+// generate a key pair of the given algorithm
+func pkcs11KeyTemplates(algorithm string) (*pkcs11.Mechanism, []*pkcs11.Attribute, []*pkcs11.Attribute, error) {
+	privateTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, pkcs11KeyLabel),
+	}
+	publicTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, pkcs11KeyLabel),
+	}
+
+	if bits, ok := pkcs11RSABits[algorithm]; ok {
+		publicTemplate = append(publicTemplate,
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, bits),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		)
+		return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil), publicTemplate, privateTemplate, nil
+	}
+
+	if curve, ok := pkcs11Curves[algorithm]; ok {
+		params, err := asn1.Marshal(curve.oid)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		publicTemplate = append(publicTemplate, pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params))
+		return pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil), publicTemplate, privateTemplate, nil
+	}
+
+	return nil, nil, nil, log.RecordedError(log.CAKC113, algorithm, KeyProviderPKCS11)
+}
+
+func generatePKCS11Key(
+	ctx *pkcs11.Ctx,
+	session pkcs11.SessionHandle,
+	algorithm string,
+	mechanism *pkcs11.Mechanism,
+	publicTemplate, privateTemplate []*pkcs11.Attribute,
+) (*pkcs11Signer, error) {
+	publicKey, privateKey, err := ctx.GenerateKeyPair(session, []*pkcs11.Mechanism{mechanism}, publicTemplate, privateTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &pkcs11Signer{ctx: ctx, session: session, privateKey: privateKey}
+	if curve, ok := pkcs11Curves[algorithm]; ok {
+		signer.public, err = ecdsaPublicKey(ctx, session, publicKey, curve.curve)
+	} else {
+		signer.public, err = rsaPublicKey(ctx, session, publicKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return signer, nil
+}
+
+func rsaPublicKey(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, object pkcs11.ObjectHandle) (*rsa.PublicKey, error) {
+	attributes, err := ctx.GetAttributeValue(session, object, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(attributes[0].Value),
+		E: int(new(big.Int).SetBytes(attributes[1].Value).Int64()),
+	}, nil
+}
+
+func ecdsaPublicKey(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, object pkcs11.ObjectHandle, curve elliptic.Curve) (*ecdsa.PublicKey, error) {
+	attributes, err := ctx.GetAttributeValue(session, object, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// CKA_EC_POINT holds a DER encoded OCTET STRING, although some tokens
+	// return the bare point
+	point := attributes[0].Value
+	var unwrapped []byte
+	if rest, err := asn1.Unmarshal(point, &unwrapped); err == nil && len(rest) == 0 {
+		point = unwrapped
+	}
+
+	// Only the uncompressed form, 0x04 || X || Y, is expected
+	size := (curve.Params().BitSize + 7) / 8
+	if len(point) != 1+2*size || point[0] != 4 {
+		return nil, fmt.Errorf("unexpected EC point encoding")
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(point[1 : 1+size]),
+		Y:     new(big.Int).SetBytes(point[1+size:]),
+	}, nil
+}
+
+// Public returns the public key of the pair generated in the token
+func (signer *pkcs11Signer) Public() crypto.PublicKey {
+	return signer.public
+}
+
+// Close ends the session, which destroys the private key, and releases the
+// module. The signer cannot be used afterwards.
+func (signer *pkcs11Signer) Close() error {
+	signer.mutex.Lock()
+	defer signer.mutex.Unlock()
+
+	if signer.closed {
+		return nil
+	}
+	signer.closed = true
+
+	err := signer.ctx.CloseSession(signer.session)
+	closePKCS11Module(signer.ctx, signer.modulePath)
+	return err
+}
+
+// Sign signs the digest with the private key held in the token
+func (signer *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	mechanism, input, err := signer.signMechanism(digest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// A PKCS#11 session supports a single operation at a time
+	signer.mutex.Lock()
+	defer signer.mutex.Unlock()
+
+	if signer.closed {
+		return nil, errors.New("the PKCS#11 session is closed")
+	}
+
+	err = signer.ctx.SignInit(signer.session, []*pkcs11.Mechanism{mechanism}, signer.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signer.ctx.Sign(signer.session, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := signer.public.(*ecdsa.PublicKey); ok {
+		// CKM_ECDSA returns r || s, whereas Go expects an ASN.1 sequence
+		half := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			new(big.Int).SetBytes(signature[:half]),
+			new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+func (signer *pkcs11Signer) signMechanism(digest []byte, opts crypto.SignerOpts) (*pkcs11.Mechanism, []byte, error) {
+	switch public := signer.public.(type) {
+	case *ecdsa.PublicKey:
+		return pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil), digest, nil
+
+	case *rsa.PublicKey:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			hash := pss.HashFunc()
+			mechanisms, ok := pssMechanisms[hash]
+			if !ok {
+				return nil, nil, fmt.Errorf("unsupported PSS hash %v", hash)
+			}
+
+			saltLength := pss.SaltLength
+			switch saltLength {
+			case rsa.PSSSaltLengthEqualsHash:
+				saltLength = hash.Size()
+			case rsa.PSSSaltLengthAuto:
+				saltLength = (public.N.BitLen()-1+7)/8 - hash.Size() - 2
+			}
+
+			params := pkcs11.NewPSSParams(mechanisms.hash, mechanisms.mgf, uint(saltLength))
+			return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, params), digest, nil
+		}
+
+		prefix, ok := pkcs1DigestInfoPrefixes[opts.HashFunc()]
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported PKCS#1 v1.5 hash %v", opts.HashFunc())
+		}
+		return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil), append(append([]byte{}, prefix...), digest...), nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !cgo
+
+package k8s
+
+import (
+	"crypto"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// newPKCS11Signer is unavailable without cgo, which is needed to load the
+// PKCS#11 module
+func newPKCS11Signer(config PKCS11Config, algorithm string) (crypto.Signer, error) {
+	return nil, log.RecordedError(log.CAKC114, config.ModulePath, "the client was built without cgo")
+}
@@ -0,0 +1,221 @@
+//go:build cgo
+
+package k8s
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	softHSMTokenLabel = "authn-k8s-test"
+	softHSMPIN        = "1234"
+	softHSMSOPIN      = "123456"
+)
+
+// findSoftHSM returns the path of the SoftHSM v2 module, which can be
+// overridden with SOFTHSM2_MODULE. The tests fail rather than skip when
+// SOFTHSM2_MODULE is set but missing, as it is in the test image.
+func findSoftHSM(t *testing.T) string {
+	if module := os.Getenv("SOFTHSM2_MODULE"); module != "" {
+		if _, err := os.Stat(module); err != nil {
+			t.Fatalf("SOFTHSM2_MODULE %s: %s", module, err)
+		}
+		return module
+	}
+
+	candidates := []string{
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// initSoftHSMToken initialises a SoftHSM token in a temporary directory and
+// returns the configuration selecting it
+func initSoftHSMToken(t *testing.T) PKCS11Config {
+	module := findSoftHSM(t)
+	if module == "" {
+		t.Skip("SoftHSM v2 is not installed, set SOFTHSM2_MODULE to run the PKCS#11 tests")
+	}
+
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	assert.NoError(t, os.Mkdir(tokenDir, 0700))
+	confPath := filepath.Join(dir, "softhsm2.conf")
+	conf := fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\n", tokenDir)
+	assert.NoError(t, os.WriteFile(confPath, []byte(conf), 0600))
+	t.Setenv("SOFTHSM2_CONF", confPath)
+
+	pinPath := filepath.Join(dir, "pin")
+	assert.NoError(t, os.WriteFile(pinPath, []byte(softHSMPIN+"\n"), 0600))
+
+	ctx := pkcs11.New(module)
+	if !assert.NotNil(t, ctx) {
+		t.FailNow()
+	}
+	defer ctx.Destroy()
+	if !assert.NoError(t, ctx.Initialize()) {
+		t.FailNow()
+	}
+	defer ctx.Finalize()
+
+	slots, err := ctx.GetSlotList(false)
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, slots) {
+		t.FailNow()
+	}
+	assert.NoError(t, ctx.InitToken(slots[0], softHSMSOPIN, softHSMTokenLabel))
+
+	// SoftHSM moves the initialised token to a new slot
+	slots, _ = ctx.GetSlotList(true)
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil || info.Label != softHSMTokenLabel {
+			continue
+		}
+		session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		assert.NoError(t, err)
+		assert.NoError(t, ctx.Login(session, pkcs11.CKU_SO, softHSMSOPIN))
+		assert.NoError(t, ctx.InitPIN(session, softHSMPIN))
+		ctx.Logout(session)
+		ctx.CloseSession(session)
+	}
+
+	return PKCS11Config{
+		ModulePath: module,
+		TokenLabel: softHSMTokenLabel,
+		PINPath:    pinPath,
+	}
+}
+
+func TestPKCS11Signer(t *testing.T) {
+	config := initSoftHSMToken(t)
+
+	testCases := []struct {
+		algorithm string
+		assertKey func(t *testing.T, public crypto.PublicKey)
+	}{
+		{
+			algorithm: KeyAlgorithmECDSAP256,
+			assertKey: func(t *testing.T, public crypto.PublicKey) {
+				assert.Equal(t, "P-256", public.(*ecdsa.PublicKey).Curve.Params().Name)
+			},
+		},
+		{
+			algorithm: KeyAlgorithmECDSAP384,
+			assertKey: func(t *testing.T, public crypto.PublicKey) {
+				assert.Equal(t, "P-384", public.(*ecdsa.PublicKey).Curve.Params().Name)
+			},
+		},
+		{
+			algorithm: KeyAlgorithmRSA2048,
+			assertKey: func(t *testing.T, public crypto.PublicKey) {
+				assert.Equal(t, 2048, public.(*rsa.PublicKey).N.BitLen())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			signer, signatureAlgorithm, err := newSigner(Config{
+				KeyAlgorithm: tc.algorithm,
+				KeyProvider:  KeyProviderPKCS11,
+				PKCS11:       config,
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer signer.(io.Closer).Close()
+			assert.IsType(t, &pkcs11Signer{}, signer)
+			tc.assertKey(t, signer.Public())
+
+			// Used for the login CSR
+			assertSigns(t, signer, signatureAlgorithm)
+
+			// Used for the TLS 1.3 handshake, which requires RSA-PSS
+			if public, ok := signer.Public().(*rsa.PublicKey); ok {
+				digest := sha256.Sum256([]byte("handshake"))
+				opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+				signature, err := signer.Sign(rand.Reader, digest[:], opts)
+				assert.NoError(t, err)
+				assert.NoError(t, rsa.VerifyPSS(public, crypto.SHA256, digest[:], signature, opts))
+			}
+		})
+	}
+
+	t.Run("private key is not extractable", func(t *testing.T) {
+		signer, _, err := newSigner(Config{
+			KeyAlgorithm: KeyAlgorithmECDSAP256,
+			KeyProvider:  KeyProviderPKCS11,
+			PKCS11:       config,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer signer.(io.Closer).Close()
+
+		p11 := signer.(*pkcs11Signer)
+		attributes, err := p11.ctx.GetAttributeValue(p11.session, p11.privateKey, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, nil),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1}, attributes[0].Value)
+		assert.Equal(t, []byte{0}, attributes[1].Value)
+	})
+
+	t.Run("Close ends the session and releases the module", func(t *testing.T) {
+		first, _, err := newSigner(Config{KeyAlgorithm: KeyAlgorithmECDSAP256, KeyProvider: KeyProviderPKCS11, PKCS11: config})
+		if !assert.NoError(t, err) {
+			return
+		}
+		second, _, err := newSigner(Config{KeyAlgorithm: KeyAlgorithmECDSAP256, KeyProvider: KeyProviderPKCS11, PKCS11: config})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 2, pkcs11Modules.signers[config.ModulePath])
+
+		// The module stays initialized for the other signer
+		assert.NoError(t, first.(io.Closer).Close())
+		assert.NoError(t, first.(io.Closer).Close())
+		assert.Equal(t, 1, pkcs11Modules.signers[config.ModulePath])
+		digest := sha256.Sum256([]byte("csr"))
+		_, err = first.Sign(rand.Reader, digest[:], crypto.SHA256)
+		assert.Error(t, err)
+		_, err = second.Sign(rand.Reader, digest[:], crypto.SHA256)
+		assert.NoError(t, err)
+
+		assert.NoError(t, second.(io.Closer).Close())
+		assert.NotContains(t, pkcs11Modules.signers, config.ModulePath)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		unknown := config
+		unknown.TokenLabel = "no-such-token"
+
+		_, _, err := newSigner(Config{
+			KeyAlgorithm: KeyAlgorithmECDSAP256,
+			KeyProvider:  KeyProviderPKCS11,
+			PKCS11:       unknown,
+		})
+		assert.EqualError(t, err, `CAKC115 PKCS#11 token "no-such-token" not found`)
+	})
+}
@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"context"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+	"github.com/cyberark/conjur-opentelemetry-tracer/pkg/trace"
+)
+
+// minRenewalDelay bounds how often the client certificate is renewed in the
+// background, so that short-lived certificates do not cause a login loop
+var minRenewalDelay = 30 * time.Second
+
+// CertificateStatus describes the client certificate and its renewal
+type CertificateStatus struct {
+	// LoggedIn is false until the first client certificate is loaded, in
+	// which case the other fields are zero
+	LoggedIn  bool
+	NotBefore time.Time
+	NotAfter  time.Time
+	// RenewAt is when the certificate will be renewed in the background. It
+	// is zero when background renewal is disabled.
+	RenewAt time.Time
+	// Renewing is true while a background renewal is in progress. The current
+	// certificate is used until the renewed one is loaded.
+	Renewing bool
+	// Renewals counts the successful background renewals
+	Renewals int
+	// LastRenewalError is the error of the last background renewal, or nil if
+	// it succeeded
+	LastRenewalError error
+}
+
+// renewal holds the state of the background renewal of the client certificate
+type renewal struct {
+	mutex     sync.Mutex
+	timer     *time.Timer
+	renewAt   time.Time
+	renewing  bool
+	renewals  int
+	lastError error
+	// stopped is set by Stop, after which no renewal is started
+	stopped bool
+	// running tracks the renewal in progress, which Stop waits for
+	running sync.WaitGroup
+}
+
+// CertificateStatus returns the lifetime and renewal state of the client
+// certificate
+func (auth *Authenticator) CertificateStatus() CertificateStatus {
+	cert := auth.certificate()
+	if cert == nil {
+		return CertificateStatus{}
+	}
+
+	auth.renewal.mutex.Lock()
+	defer auth.renewal.mutex.Unlock()
+
+	return CertificateStatus{
+		LoggedIn:         true,
+		NotBefore:        cert.NotBefore,
+		NotAfter:         cert.NotAfter,
+		RenewAt:          auth.renewal.renewAt,
+		Renewing:         auth.renewal.renewing,
+		Renewals:         auth.renewal.renewals,
+		LastRenewalError: auth.renewal.lastError,
+	}
+}
+
+// scheduleRenewal arranges for the certificate to be renewed once the
+// configured fraction of its lifetime has passed
+func (auth *Authenticator) scheduleRenewal(logger log.RequestLogger, cert *x509.Certificate) {
+	fraction := auth.config.RenewalFraction
+	if fraction <= 0 {
+		return
+	}
+
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	renewAt := cert.NotBefore.Add(time.Duration(float64(lifetime) * fraction))
+	delay := auth.startRenewalTimer(renewAt)
+
+	logger.Debug(log.CAKC123, cert.NotAfter, time.Now().Add(delay))
+}
+
+// startRenewalTimer starts the background renewal at renewAt, or after
+// minRenewalDelay if that is later, and returns the delay
+func (auth *Authenticator) startRenewalTimer(renewAt time.Time) time.Duration {
+	delay := time.Until(renewAt)
+	if delay < minRenewalDelay {
+		delay = minRenewalDelay
+	}
+
+	auth.renewal.mutex.Lock()
+	defer auth.renewal.mutex.Unlock()
+
+	if auth.renewal.stopped {
+		return delay
+	}
+	if auth.renewal.timer != nil {
+		auth.renewal.timer.Stop()
+	}
+	auth.renewal.timer = time.AfterFunc(delay, auth.renewInBackground)
+	auth.renewal.renewAt = time.Now().Add(delay)
+
+	return delay
+}
+
+// renewInBackground logs in again to obtain a new client certificate. The
+// current certificate remains in use until the new one is loaded.
+func (auth *Authenticator) renewInBackground() {
+	cert := auth.certificate()
+
+	auth.renewal.mutex.Lock()
+	if auth.renewal.stopped {
+		auth.renewal.mutex.Unlock()
+		return
+	}
+	auth.renewal.renewing = true
+	auth.renewal.running.Add(1)
+	auth.renewal.mutex.Unlock()
+	defer auth.renewal.running.Done()
+
+	logger := log.WithRequestID(common.NewRequestID())
+	logger.Info(log.CAKC121, cert.NotAfter)
+
+	tr := trace.NewOtelTracer(otel.Tracer("conjur-authn-k8s-client"))
+	ctx, span := tr.Start(context.Background(), "Renew client certificate")
+	span.SetAttributes(attribute.String(common.RequestIDAttribute, logger.RequestID()))
+	err := auth.login(log.NewContext(ctx, logger), tr)
+	if err != nil {
+		span.RecordErrorAndSetStatus(err)
+	}
+	span.End()
+
+	auth.renewal.mutex.Lock()
+	auth.renewal.renewing = false
+	auth.renewal.lastError = err
+	if err == nil {
+		auth.renewal.renewals++
+	}
+	auth.renewal.mutex.Unlock()
+
+	if err == nil {
+		return
+	}
+
+	// Retry while the current certificate is still valid. Once it expires,
+	// the next authentication logs in again.
+	if time.Now().Add(minRenewalDelay).Before(cert.NotAfter) {
+		logger.Warn(log.CAKC122, minRenewalDelay, err)
+		auth.startRenewalTimer(time.Now())
+	}
+}
+
+// stopRenewal cancels the scheduled renewal and waits for the one in progress,
+// if any. No renewal is started afterwards.
+func (auth *Authenticator) stopRenewal() {
+	auth.renewal.mutex.Lock()
+	auth.renewal.stopped = true
+	if auth.renewal.timer != nil {
+		auth.renewal.timer.Stop()
+	}
+	auth.renewal.renewAt = time.Time{}
+	auth.renewal.mutex.Unlock()
+
+	auth.renewal.running.Wait()
+}
@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"context"
+	"encoding/pem"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/access_token/memory"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+)
+
+// newRenewingAuthenticator returns an authenticator that has logged in to a
+// TestAuthServer issuing certificates valid for the given lifetime
+func newRenewingAuthenticator(t *testing.T, lifetime time.Duration, fraction float64) (*Authenticator, *common.TestAuthServer) {
+	tmpDir := t.TempDir()
+	clientCertPath := filepath.Join(tmpDir, "etc:conjur:ssl:client.pem")
+	certLogPath := filepath.Join(tmpDir, "tmp:conjur_copy_text_output.log")
+
+	ts := common.NewTestAuthServer(clientCertPath, certLogPath, "some token", false)
+	ts.CertLifetime = lifetime
+	t.Cleanup(ts.Server.Close)
+
+	at, _ := memory.NewAccessToken()
+	sslcert := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Server.Certificate().Raw,
+	})
+	username, _ := common.NewUsername("host/test-user")
+
+	authn, err := NewWithAccessToken(Config{
+		InjectCertLogPath: certLogPath,
+		PodName:           "testPodName",
+		PodNamespace:      "testPodNamespace",
+		KeyAlgorithm:      KeyAlgorithmECDSAP256,
+		RenewalFraction:   fraction,
+		Common: common.Config{
+			SSLCertificate: sslcert,
+			TokenFilePath:  filepath.Join(tmpDir, "run:conjur:access-token"),
+			URL:            ts.Server.URL,
+			Username:       username,
+			Account:        "account",
+			ClientCertPath: clientCertPath,
+		},
+	}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(authn.Stop)
+
+	assert.Equal(t, CertificateStatus{}, authn.CertificateStatus())
+	if err := authn.AuthenticateWithContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return authn, ts
+}
+
+func TestCertificateRenewal(t *testing.T) {
+	defer func(delay time.Duration) { minRenewalDelay = delay }(minRenewalDelay)
+	minRenewalDelay = 100 * time.Millisecond
+
+	t.Run("renews the certificate in the background", func(t *testing.T) {
+		authn, _ := newRenewingAuthenticator(t, 2*time.Second, 0.5)
+		first := authn.certificate()
+
+		status := authn.CertificateStatus()
+		assert.True(t, status.LoggedIn)
+		assert.Equal(t, first.NotBefore, status.NotBefore)
+		assert.Equal(t, first.NotAfter, status.NotAfter)
+		assert.True(t, status.RenewAt.After(status.NotBefore))
+		assert.True(t, status.RenewAt.Before(status.NotAfter))
+		assert.Zero(t, status.Renewals)
+
+		assert.Eventually(t, func() bool {
+			return authn.CertificateStatus().Renewals > 0
+		}, 5*time.Second, 50*time.Millisecond)
+
+		status = authn.CertificateStatus()
+		assert.NotSame(t, first, authn.certificate())
+		assert.False(t, status.Renewing)
+		assert.NoError(t, status.LastRenewalError)
+		assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+	})
+
+	t.Run("keeps the current certificate when renewal fails", func(t *testing.T) {
+		authn, ts := newRenewingAuthenticator(t, time.Hour, 0.0001)
+		first := authn.certificate()
+		ts.Server.Close()
+
+		assert.Eventually(t, func() bool {
+			return authn.CertificateStatus().LastRenewalError != nil
+		}, 5*time.Second, 50*time.Millisecond)
+
+		status := authn.CertificateStatus()
+		assert.Zero(t, status.Renewals)
+		assert.Same(t, first, authn.certificate())
+		assert.True(t, status.RenewAt.After(time.Now().Add(-time.Second)))
+	})
+
+	t.Run("Stop cancels the renewal", func(t *testing.T) {
+		authn, _ := newRenewingAuthenticator(t, 2*time.Second, 0.5)
+		authn.Stop()
+
+		status := authn.CertificateStatus()
+		assert.True(t, status.RenewAt.IsZero())
+		time.Sleep(1500 * time.Millisecond)
+		assert.Zero(t, authn.CertificateStatus().Renewals)
+
+		// Logging in again does not schedule a renewal
+		authn.setCertificate(nil)
+		assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+		assert.True(t, authn.CertificateStatus().RenewAt.IsZero())
+	})
+
+	t.Run("renewal disabled", func(t *testing.T) {
+		authn, _ := newRenewingAuthenticator(t, time.Hour, 0)
+
+		status := authn.CertificateStatus()
+		assert.True(t, status.LoggedIn)
+		assert.True(t, status.RenewAt.IsZero())
+		assert.Nil(t, authn.renewal.timer)
+	})
+}
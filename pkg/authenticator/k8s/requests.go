@@ -2,46 +2,70 @@ package k8s
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
 
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 )
 
 // LoginRequest sends a login request
 func LoginRequest(authnURL string, csrBytes []byte, usernamePrefix string) (*http.Request, error) {
+	return LoginRequestWithContext(context.Background(), authnURL, csrBytes, usernamePrefix)
+}
+
+// LoginRequestWithContext sends a login request bound to ctx, logging with
+// its request logger
+func LoginRequestWithContext(ctx context.Context, authnURL string, csrBytes []byte, usernamePrefix string) (*http.Request, error) {
 	var authenticateURL string
+	logger := log.FromContext(ctx)
 
 	authenticateURL = fmt.Sprintf("%s/inject_client_cert", authnURL)
 
-	log.Debug(log.CAKC045, authenticateURL)
+	logger.Debug(log.CAKC045, authenticateURL)
 
-	req, err := http.NewRequest("POST", authenticateURL, bytes.NewBuffer(csrBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", authenticateURL, bytes.NewBuffer(csrBytes))
 	if err != nil {
-		return nil, log.RecordedError(log.CAKC024, err)
+		return nil, logger.RecordedError(log.CAKC024, err)
 	}
 	req.Header.Set("Content-Type", "text/plain")
 	req.Header.Set("Host-Id-Prefix", usernamePrefix)
+	req.Header.Set("User-Agent", common.UserAgent(AuthnType))
 
 	return req, nil
 }
 
 // AuthenticateRequest sends an authenticate request
 func AuthenticateRequest(authnURL string, account string, username string) (*http.Request, error) {
+	return AuthenticateRequestWithContext(context.Background(), authnURL, account, username)
+}
+
+// AuthenticateRequestWithContext sends an authenticate request bound to ctx,
+// logging with its request logger
+func AuthenticateRequestWithContext(ctx context.Context, authnURL string, account string, username string) (*http.Request, error) {
 	var authenticateURL string
 	var err error
 	var req *http.Request
+	logger := log.FromContext(ctx)
 
 	authenticateURL = fmt.Sprintf("%s/%s/%s/authenticate", authnURL, account, url.QueryEscape(username))
 
-	log.Debug(log.CAKC046, authenticateURL)
+	logger.Debug(log.CAKC046, authenticateURL)
 
-	if req, err = http.NewRequest("POST", authenticateURL, nil); err != nil {
-		return nil, log.RecordedError(log.CAKC023, err)
+	if req, err = http.NewRequestWithContext(ctx, "POST", authenticateURL, nil); err != nil {
+		return nil, logger.RecordedError(log.CAKC023, err)
 	}
 
 	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("User-Agent", common.UserAgent(AuthnType))
 
 	return req, nil
 }
+
+// acceptEncryptedToken advertises that the client can decrypt an access token
+// encrypted for its client certificate. Conjur may still return it as JSON.
+func acceptEncryptedToken(req *http.Request) {
+	req.Header.Set("Accept", "application/pkcs7-mime, application/json;q=0.9")
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
 )
 
 func TestLoginRequest(t *testing.T) {
@@ -19,4 +21,5 @@ func TestLoginRequest(t *testing.T) {
 
 	// ASSERT
 	assert.Equal(t, "host.path.to.policy", req.Header.Get("Host-Id-Prefix"))
+	assert.Equal(t, common.UserAgent(AuthnType), req.Header.Get("User-Agent"))
 }
@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+var (
+	podUIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	// Service account names are DNS subdomains, see RFC 1123
+	serviceAccountPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// subjectAltNames validates the pod identity and returns the SANs of the login
+// CSR. The SPIFFE ID of the pod always comes first, the pod UID, service
+// account and pod IP follow when they are configured.
+func subjectAltNames(config Config) ([]*url.URL, []net.IP, error) {
+	if config.PodNamespace == "" || config.PodName == "" {
+		return nil, nil, log.RecordedError(log.CAKC008, config.PodNamespace, config.PodName)
+	}
+
+	trustDomainName := config.SPIFFETrustDomain
+	if trustDomainName == "" {
+		trustDomainName = DefaultSPIFFETrustDomain
+	}
+	trustDomain, err := spiffeid.TrustDomainFromString(trustDomainName)
+	if err != nil {
+		return nil, nil, log.RecordedError(log.CAKC060, "CONJUR_SPIFFE_TRUST_DOMAIN", trustDomainName)
+	}
+
+	for _, setting := range []struct{ name, value string }{
+		{"MY_POD_NAMESPACE", config.PodNamespace},
+		{"MY_POD_NAME", config.PodName},
+	} {
+		if err := spiffeid.ValidatePathSegment(setting.value); err != nil {
+			return nil, nil, log.RecordedError(log.CAKC060, setting.name, setting.value)
+		}
+	}
+	spiffeID, err := spiffeid.FromSegments(trustDomain, "namespace", config.PodNamespace, "podname", config.PodName)
+	if err != nil {
+		return nil, nil, log.RecordedError(log.CAKC060, "CONJUR_SPIFFE_TRUST_DOMAIN", trustDomainName)
+	}
+	uris := []*url.URL{spiffeID.URL()}
+
+	if config.PodUID != "" {
+		if !podUIDPattern.MatchString(config.PodUID) {
+			return nil, nil, log.RecordedError(log.CAKC060, "MY_POD_UID", config.PodUID)
+		}
+		uris = append(uris, &url.URL{Scheme: "urn", Opaque: "uuid:" + config.PodUID})
+	}
+
+	if config.PodServiceAccount != "" {
+		if len(config.PodServiceAccount) > 253 || !serviceAccountPattern.MatchString(config.PodServiceAccount) {
+			return nil, nil, log.RecordedError(log.CAKC060, "MY_POD_SERVICE_ACCOUNT", config.PodServiceAccount)
+		}
+		uris = append(uris, &url.URL{
+			Scheme: "urn",
+			Opaque: "k8s:serviceaccount:" + config.PodNamespace + ":" + config.PodServiceAccount,
+		})
+	}
+
+	var ips []net.IP
+	if config.PodIP != "" {
+		ip := net.ParseIP(config.PodIP)
+		if ip == nil {
+			return nil, nil, log.RecordedError(log.CAKC060, "MY_POD_IP", config.PodIP)
+		}
+		ips = append(ips, ip)
+	}
+
+	return uris, ips, nil
+}
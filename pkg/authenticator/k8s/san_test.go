@@ -0,0 +1,113 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectAltNames(t *testing.T) {
+	valid := Config{
+		PodName:      "app-7d9f8",
+		PodNamespace: "apps",
+	}
+
+	testCases := []struct {
+		description string
+		configure   func(config *Config)
+		uris        []string
+		ips         []string
+		err         string
+	}{
+		{
+			description: "default trust domain",
+			configure:   func(config *Config) {},
+			uris:        []string{"spiffe://cluster.local/namespace/apps/podname/app-7d9f8"},
+		},
+		{
+			description: "trust domain given as a SPIFFE ID",
+			configure: func(config *Config) {
+				config.SPIFFETrustDomain = "spiffe://east.example.com"
+			},
+			uris: []string{"spiffe://east.example.com/namespace/apps/podname/app-7d9f8"},
+		},
+		{
+			description: "IPv6 pod IP",
+			configure: func(config *Config) {
+				config.PodIP = "fd00::12"
+			},
+			uris: []string{"spiffe://cluster.local/namespace/apps/podname/app-7d9f8"},
+			ips:  []string{"fd00::12"},
+		},
+		{
+			description: "empty namespace",
+			configure: func(config *Config) {
+				config.PodNamespace = ""
+			},
+			err: "CAKC008 Namespace or podname can't be empty namespace= podname=app-7d9f8",
+		},
+		{
+			description: "invalid pod name",
+			configure: func(config *Config) {
+				config.PodName = "app/7d9f8"
+			},
+			err: "CAKC060 Setting MY_POD_NAME given invalid value app/7d9f8",
+		},
+		{
+			description: "invalid trust domain",
+			configure: func(config *Config) {
+				config.SPIFFETrustDomain = "Cluster.Local"
+			},
+			err: "CAKC060 Setting CONJUR_SPIFFE_TRUST_DOMAIN given invalid value Cluster.Local",
+		},
+		{
+			description: "invalid pod UID",
+			configure: func(config *Config) {
+				config.PodUID = "not-a-uid"
+			},
+			err: "CAKC060 Setting MY_POD_UID given invalid value not-a-uid",
+		},
+		{
+			description: "invalid service account",
+			configure: func(config *Config) {
+				config.PodServiceAccount = "App:Admin"
+			},
+			err: "CAKC060 Setting MY_POD_SERVICE_ACCOUNT given invalid value App:Admin",
+		},
+		{
+			description: "invalid pod IP",
+			configure: func(config *Config) {
+				config.PodIP = "10.0.0.300"
+			},
+			err: "CAKC060 Setting MY_POD_IP given invalid value 10.0.0.300",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			config := valid
+			tc.configure(&config)
+
+			uris, ips, err := subjectAltNames(config)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var uriStrings []string
+			for _, uri := range uris {
+				uriStrings = append(uriStrings, uri.String())
+			}
+			assert.Equal(t, tc.uris, uriStrings)
+
+			var ipStrings []string
+			for _, ip := range ips {
+				ipStrings = append(ipStrings, ip.String())
+			}
+			assert.Equal(t, tc.ips, ipStrings)
+		})
+	}
+}
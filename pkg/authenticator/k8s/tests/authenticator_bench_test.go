@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/k8s"
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
+)
+
+// newLoggedInAuthenticator returns an authenticator that has logged in to a
+// TestAuthServer, along with the server
+func newLoggedInAuthenticator(tb testing.TB) (*k8s.Authenticator, *common.TestAuthServer) {
+	ts := newTestAuthServer(tb, "some token", false)
+	authn := newTestAuthenticator(tb, ts, nil)
+
+	if err := authn.AuthenticateWithContext(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+
+	return authn, ts
+}
+
+func TestAuthenticator_ReusesConnection(t *testing.T) {
+	authn, ts := newLoggedInAuthenticator(t)
+
+	// One connection for the login and one for the mutual TLS authentication
+	assert.EqualValues(t, 2, atomic.LoadInt32(&ts.Connections))
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt32(&ts.Connections))
+
+	// A renewed certificate needs a new mutual TLS connection
+	cert := *authn.PublicCert
+	authn.PublicCert = &cert
+	assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+	assert.EqualValues(t, 3, atomic.LoadInt32(&ts.Connections))
+}
+
+func BenchmarkAuthenticator_Authenticate(b *testing.B) {
+	log.SetLogLevel("warn")
+	defer log.SetLogLevel("info")
+
+	b.Run("client reused per certificate", func(b *testing.B) {
+		authn, ts := newLoggedInAuthenticator(b)
+		start := atomic.LoadInt32(&ts.Connections)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := authn.AuthenticateWithContext(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(atomic.LoadInt32(&ts.Connections)-start)/float64(b.N), "conns/op")
+	})
+
+	// Presenting a different certificate object on every request forces a new
+	// client, which is how every request behaved before clients were reused
+	b.Run("client per request", func(b *testing.B) {
+		authn, ts := newLoggedInAuthenticator(b)
+		start := atomic.LoadInt32(&ts.Connections)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			cert := *authn.PublicCert
+			authn.PublicCert = &cert
+			if err := authn.AuthenticateWithContext(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(atomic.LoadInt32(&ts.Connections)-start)/float64(b.N), "conns/op")
+	})
+}
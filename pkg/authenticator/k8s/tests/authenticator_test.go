@@ -6,7 +6,10 @@ import (
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/pem"
+	"net/http"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,18 +29,70 @@ type assertFunc func(t *testing.T,
 	logTxt string,
 )
 
+// newTestAuthServer starts a TestAuthServer responding with the given token.
+// Its client certificate and injection log are written to a temporary
+// directory, so that tests can run in parallel.
+func newTestAuthServer(tb testing.TB, token string, skipWritingCSRFile bool) *common.TestAuthServer {
+	tmpDir := tb.TempDir()
+	ts := common.NewTestAuthServer(
+		filepath.Join(tmpDir, "etc:conjur:ssl:client.pem"),
+		filepath.Join(tmpDir, "tmp:conjur_copy_text_output.log"),
+		token,
+		skipWritingCSRFile,
+	)
+	tb.Cleanup(ts.Server.Close)
+	return ts
+}
+
+// newTestAuthenticator creates an authenticator for the TestAuthServer, using
+// an ECDSA P-256 key. configure, if not nil, changes the configuration first.
+func newTestAuthenticator(tb testing.TB, ts *common.TestAuthServer, configure func(cfg *k8s.Config)) *k8s.Authenticator {
+	sslcert := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Server.Certificate().Raw,
+	})
+	username, _ := common.NewUsername("host/test-user")
+
+	cfg := k8s.Config{
+		InjectCertLogPath: ts.CertLogPath,
+		PodName:           "testPodName",
+		PodNamespace:      "testPodNamespace",
+		KeyAlgorithm:      k8s.KeyAlgorithmECDSAP256,
+		Common: common.Config{
+			SSLCertificate: sslcert,
+			TokenFilePath:  filepath.Join(filepath.Dir(ts.ClientCertPath), "run:conjur:access-token"),
+			URL:            ts.Server.URL,
+			Username:       username,
+			Account:        "account",
+			ClientCertPath: ts.ClientCertPath,
+		},
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+
+	at, _ := memory.NewAccessToken()
+	authn, err := k8s.NewWithAccessToken(cfg, at)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(authn.Stop)
+	return authn
+}
+
 func TestAuthenticator_Authenticate(t *testing.T) {
 	testCases := []struct {
 		name               string
-		podName            string
-		podNamespace       string
+		keyAlgorithm       string
+		configure          func(cfg *k8s.Config)
 		skipWritingCSRFile bool
+		injectionLog       string
+		maxDuration        time.Duration
 		assert             assertFunc
 	}{
 		{
 			name:         "happy path",
-			podName:      "testPodName",
-			podNamespace: "testPodNamespace",
+			keyAlgorithm: k8s.DefaultKeyAlgorithm,
 			assert: func(t *testing.T, authn *k8s.Authenticator, err error, loginCsr *x509.CertificateRequest, loginCsrErr error, _ string) {
 				assert.NoError(t, err)
 
@@ -66,24 +121,86 @@ func TestAuthenticator_Authenticate(t *testing.T) {
 			},
 		},
 		{
-			name:         "empty podname",
-			podName:      "",
-			podNamespace: "",
+			name:         "ECDSA P-256 key",
+			keyAlgorithm: k8s.KeyAlgorithmECDSAP256,
 			assert: func(t *testing.T, authn *k8s.Authenticator, err error, loginCsr *x509.CertificateRequest, _ error, _ string) {
 				assert.NoError(t, err)
+				assert.Equal(t, x509.ECDSA, loginCsr.PublicKeyAlgorithm)
+				assert.Equal(t, x509.ECDSAWithSHA256, loginCsr.SignatureAlgorithm)
+				assert.NoError(t, loginCsr.CheckSignature())
 
-				// Assert empty spiffe
-				assert.Len(t, loginCsr.Extensions, 1)
-				var sans []asn1.RawValue
-				_, _ = asn1.Unmarshal(loginCsr.Extensions[0].Value, &sans)
-				assert.Len(t, sans, 1)
-				assert.Equal(t, "", string(sans[0].Bytes))
+				// The client certificate is usable for mutual TLS
+				token, _ := authn.GetAccessToken().Read()
+				assert.Equal(t, token, []byte("some token"))
+			},
+		},
+		{
+			name:         "ECDSA P-384 key",
+			keyAlgorithm: k8s.KeyAlgorithmECDSAP384,
+			assert: func(t *testing.T, authn *k8s.Authenticator, err error, loginCsr *x509.CertificateRequest, _ error, _ string) {
+				assert.NoError(t, err)
+				assert.Equal(t, x509.ECDSAWithSHA384, loginCsr.SignatureAlgorithm)
+			},
+		},
+		{
+			name:         "RSA 2048 key",
+			keyAlgorithm: k8s.KeyAlgorithmRSA2048,
+			assert: func(t *testing.T, authn *k8s.Authenticator, err error, loginCsr *x509.CertificateRequest, _ error, _ string) {
+				assert.NoError(t, err)
+				assert.Equal(t, x509.SHA256WithRSA, loginCsr.SignatureAlgorithm)
 			},
 		},
 		{
-			name:         "expired cert",
-			podName:      "testPodName",
-			podNamespace: "testPodNamespace",
+			name: "custom trust domain and additional SANs",
+			configure: func(cfg *k8s.Config) {
+				cfg.SPIFFETrustDomain = "prod.example.com"
+				cfg.PodUID = "8a3f2d1e-4b5c-4d6e-9f70-112233445566"
+				cfg.PodServiceAccount = "test-app"
+				cfg.PodIP = "10.1.2.3"
+			},
+			assert: func(t *testing.T, authn *k8s.Authenticator, err error, loginCsr *x509.CertificateRequest, _ error, _ string) {
+				assert.NoError(t, err)
+
+				var uris []string
+				for _, uri := range loginCsr.URIs {
+					uris = append(uris, uri.String())
+				}
+				assert.Equal(t, []string{
+					"spiffe://prod.example.com/namespace/testPodNamespace/podname/testPodName",
+					"urn:uuid:8a3f2d1e-4b5c-4d6e-9f70-112233445566",
+					"urn:k8s:serviceaccount:testPodNamespace:test-app",
+				}, uris)
+				if assert.Len(t, loginCsr.IPAddresses, 1) {
+					assert.Equal(t, "10.1.2.3", loginCsr.IPAddresses[0].String())
+				}
+			},
+		},
+		{
+			name: "empty podname",
+			configure: func(cfg *k8s.Config) {
+				cfg.PodName = ""
+				cfg.PodNamespace = ""
+			},
+			assert: func(t *testing.T, authn *k8s.Authenticator, err error, loginCsr *x509.CertificateRequest, _ error, logTxt string) {
+				// The CSR is not sent
+				assert.Nil(t, loginCsr)
+				assert.EqualError(t, err, log.CAKC015)
+				assert.Contains(t, logTxt, "CAKC008 Namespace or podname can't be empty")
+			},
+		},
+		{
+			name: "invalid trust domain",
+			configure: func(cfg *k8s.Config) {
+				cfg.SPIFFETrustDomain = "Prod Cluster"
+			},
+			assert: func(t *testing.T, authn *k8s.Authenticator, err error, loginCsr *x509.CertificateRequest, _ error, logTxt string) {
+				assert.Nil(t, loginCsr)
+				assert.EqualError(t, err, log.CAKC015)
+				assert.Contains(t, logTxt, "CAKC060 Setting CONJUR_SPIFFE_TRUST_DOMAIN given invalid value Prod Cluster")
+			},
+		},
+		{
+			name: "expired cert",
 			assert: func(t *testing.T, authn *k8s.Authenticator, err error, _ *x509.CertificateRequest, _ error, _ string) {
 				assert.NoError(t, err)
 				// Set the expiration date to now, and try to authenticate again
@@ -98,76 +215,201 @@ func TestAuthenticator_Authenticate(t *testing.T) {
 		},
 		{
 			name:               "injects log on failure",
-			podName:            "testPodName",
-			podNamespace:       "testPodNamespace",
 			skipWritingCSRFile: true,
 			assert: func(t *testing.T, _ *k8s.Authenticator, err error, _ *x509.CertificateRequest, _ error, logTxt string) {
-				assert.Error(t, err)
+				var injectionErr *k8s.InjectionError
+				if assert.ErrorAs(t, err, &injectionErr) {
+					assert.Equal(t, k8s.InjectionFailureUnknown, injectionErr.Failure)
+					assert.Equal(t, "error writing csr file\n", injectionErr.Log)
+				}
 				// Check logs for the expected error
 				assert.Contains(t, logTxt, "error writing csr file")
 			},
 		},
+		{
+			name:               "injection error stops the wait",
+			skipWritingCSRFile: true,
+			injectionLog:       "sh: can't create /etc/conjur/ssl/client.pem: Read-only file system\n",
+			configure: func(cfg *k8s.Config) {
+				cfg.Common.ClientCertRetryCountLimit = 200
+			},
+			// The failure is reported without waiting for the whole timeout
+			maxDuration: 5 * time.Second,
+			assert: func(t *testing.T, _ *k8s.Authenticator, err error, _ *x509.CertificateRequest, _ error, logTxt string) {
+				// The failure is logged once, with its classification
+				assert.Equal(t, 1, strings.Count(logTxt, "CAKC055"))
+				assert.Contains(t, logTxt, "CAKC055 Cert placement failed (read-only-filesystem)")
+				assert.NotContains(t, logTxt, "CAKC132")
+
+				var injectionErr *k8s.InjectionError
+				if assert.ErrorAs(t, err, &injectionErr) {
+					assert.Equal(t, k8s.InjectionFailureReadOnlyFilesystem, injectionErr.Failure)
+					assert.EqualError(t, err, "CAKC132 Conjur failed to inject the client certificate, "+
+						"the client certificate directory is read-only. "+
+						"Mount a writable volume, e.g. an emptyDir, on the directory of CONJUR_CLIENT_CERT_PATH")
+				}
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// SETUP
-			// Create a temporary file for storing the client cert. This will allow multiple tests to run in parallel
-			tmpDir := t.TempDir()
-			clientCertPath := filepath.Join(tmpDir, "etc:conjur:ssl:client.pem")
-			certLogPath := filepath.Join(tmpDir, "tmp:conjur_copy_text_output.log")
-			tokenPath := filepath.Join(tmpDir, "run:conjur:access-token")
+			// Start up a test server to mock the Conjur server's auth endpoints
 			var loginCsr *x509.CertificateRequest
 			var loginCsrErr error
-
-			// Start up a test server to mock the Conjur server's auth endpoints
-			ts := common.NewTestAuthServer(clientCertPath, certLogPath, "some token", tc.skipWritingCSRFile)
+			ts := newTestAuthServer(t, "some token", tc.skipWritingCSRFile)
 			ts.HandleLogin = func(csr *x509.CertificateRequest, err error) {
 				loginCsr = csr
 				loginCsrErr = err
 			}
-			defer ts.Server.Close()
-
-			// Create an authenticator with dummy config
-			at, _ := memory.NewAccessToken()
-			sslcert := pem.EncodeToMemory(&pem.Block{
-				Type:  "CERTIFICATE",
-				Bytes: ts.Server.Certificate().Raw,
-			})
-			username, _ := common.NewUsername("host/test-user")
-
-			cfg := k8s.Config{
-				InjectCertLogPath: certLogPath,
-				PodName:           tc.podName,
-				PodNamespace:      tc.podNamespace,
-				Common: common.Config{
-					SSLCertificate:            sslcert,
-					TokenFilePath:             tokenPath,
-					TokenRefreshTimeout:       0,
-					URL:                       ts.Server.URL,
-					Username:                  username,
-					Account:                   "account",
-					ClientCertPath:            clientCertPath,
-					ClientCertRetryCountLimit: 0,
-					ContainerMode:             "doesntmatter",
-				},
+			if tc.injectionLog != "" {
+				ts.InjectionLog = tc.injectionLog
 			}
 
-			// EXERCISE
-			authn, err := k8s.NewWithAccessToken(cfg, at)
-			if !assert.NoError(t, err) {
-				return
-			}
+			authn := newTestAuthenticator(t, ts, func(cfg *k8s.Config) {
+				if tc.keyAlgorithm != "" {
+					cfg.KeyAlgorithm = tc.keyAlgorithm
+				}
+				if tc.configure != nil {
+					tc.configure(cfg)
+				}
+			})
 
 			// Intercept the logs to check for the cert placement error
 			var logTxt bytes.Buffer
 			log.ErrorLogger.SetOutput(&logTxt)
 
+			// EXERCISE
 			// Call the main method of the authenticator. This is where most of the internal implementation happens
-			err = authn.AuthenticateWithContext(context.Background())
+			start := time.Now()
+			err := authn.AuthenticateWithContext(context.Background())
 
 			// ASSERT
+			if tc.maxDuration != 0 {
+				assert.Less(t, time.Since(start), tc.maxDuration)
+			}
 			tc.assert(t, authn, err, loginCsr, loginCsrErr, logTxt.String())
 		})
 	}
 }
+
+func TestAuthenticator_EncryptedToken(t *testing.T) {
+	testCases := []struct {
+		name            string
+		keyAlgorithm    string
+		encryptToken    bool
+		expectEncrypted bool
+	}{
+		{
+			name:            "decrypts a PKCS#7 response",
+			keyAlgorithm:    k8s.KeyAlgorithmRSA2048,
+			encryptToken:    true,
+			expectEncrypted: true,
+		},
+		{
+			name:         "falls back to a plaintext response",
+			keyAlgorithm: k8s.KeyAlgorithmRSA2048,
+		},
+		{
+			name:         "does not accept an encrypted token with an ECDSA key",
+			keyAlgorithm: k8s.KeyAlgorithmECDSAP256,
+			encryptToken: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := newTestAuthServer(t, `{"protected":"some token"}`, false)
+			ts.EncryptToken = tc.encryptToken
+			authn := newTestAuthenticator(t, ts, func(cfg *k8s.Config) {
+				cfg.KeyAlgorithm = tc.keyAlgorithm
+			})
+
+			assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+
+			token, _ := authn.GetAccessToken().Read()
+			assert.Equal(t, `{"protected":"some token"}`, string(token))
+			assert.Equal(t, tc.expectEncrypted, ts.EncryptedResponses == 1)
+		})
+	}
+}
+
+func TestAuthenticator_CertCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheKeyPath := filepath.Join(tmpDir, "cache-key")
+	assert.NoError(t, os.WriteFile(cacheKeyPath, []byte("0123456789abcdef0123456789abcdef"), 0600))
+
+	ts := newTestAuthServer(t, "some token", false)
+	logins := 0
+	ts.HandleLogin = func(*x509.CertificateRequest, error) {
+		logins++
+	}
+
+	// authenticate starts a new authenticator, as a restarted container does
+	authenticate := func(podName string) *k8s.Authenticator {
+		authn := newTestAuthenticator(t, ts, func(cfg *k8s.Config) {
+			cfg.PodName = podName
+			cfg.CertCachePath = filepath.Join(tmpDir, "client-cert.cache")
+			cfg.CertCacheKeyPath = cacheKeyPath
+		})
+		assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+		return authn
+	}
+
+	first := authenticate("testPodName")
+	assert.Equal(t, 1, logins)
+
+	// The cached certificate is reused without logging in
+	second := authenticate("testPodName")
+	assert.Equal(t, 1, logins)
+	assert.True(t, first.PublicCert.Equal(second.PublicCert))
+
+	// A certificate cached for another pod is not reused
+	authenticate("otherPodName")
+	assert.Equal(t, 2, logins)
+}
+
+func TestAuthenticator_RequestHeaders(t *testing.T) {
+	ts := newTestAuthServer(t, "some token", false)
+	var requests []http.Header
+	ts.HandleRequest = func(r *http.Request) {
+		requests = append(requests, r.Header.Clone())
+	}
+	authn := newTestAuthenticator(t, ts, nil)
+
+	log.SetLogLevel("debug")
+	defer log.SetLogLevel("info")
+	var logTxt bytes.Buffer
+	log.InfoLogger.SetOutput(&logTxt)
+	defer log.InfoLogger.SetOutput(os.Stdout)
+
+	assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+	assert.NoError(t, authn.AuthenticateWithContext(context.Background()))
+
+	// The login and authenticate requests of an attempt share its request ID
+	if !assert.Len(t, requests, 3) {
+		return
+	}
+	for _, header := range requests {
+		assert.Equal(t, common.UserAgent(k8s.AuthnType), header.Get("User-Agent"))
+	}
+	firstID := requests[0].Get(common.RequestIDHeader)
+	assert.Len(t, firstID, 32)
+	assert.Equal(t, firstID, requests[1].Get(common.RequestIDHeader))
+	assert.NotEqual(t, firstID, requests[2].Get(common.RequestIDHeader))
+
+	// The messages of the attempt are tagged with its request ID, including
+	// those of the helpers
+	for _, code := range []string{"CAKC040", "CAKC120", "CAKC035"} {
+		for _, line := range strings.Split(logTxt.String(), "\n") {
+			if strings.Contains(line, code) {
+				assert.Contains(t, line, "[request_id="+firstID+"]")
+				break
+			}
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(logTxt.String()), "\n") {
+		assert.Contains(t, line, "[request_id=")
+	}
+}
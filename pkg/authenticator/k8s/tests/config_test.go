@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/common"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/config"
 	"github.com/cyberark/conjur-authn-k8s-client/pkg/authenticator/k8s"
 )
@@ -58,6 +59,18 @@ func TestGatherSettings(t *testing.T) {
 				"CONJUR_AUTHN_LOGIN":                   "host/anotherHost", // provided by annotation
 				"CONJUR_AUTHN_URL":                     "filepath",
 				"CONJUR_CERT_FILE":                     "testSSLCertFile.txt",
+				"CONJUR_CA_SYSTEM_ROOTS":               "",
+				"CONJUR_CONNECT_TIMEOUT":               "10s",
+				"CONJUR_HTTP_HEADERS":                  "",
+				"CONJUR_HTTP_TIMEOUT":                  "10s",
+				"CONJUR_PROXY_CA_FILE":                 "",
+				"CONJUR_PROXY_URL":                     "",
+				"CONJUR_TLS_HANDSHAKE_TIMEOUT":         "10s",
+				"CONJUR_TLS_MIN_VERSION":               "1.2",
+				"CONJUR_TLS_SERVER_NAME":               "",
+				"CONJUR_SSL_BOOTSTRAP":                 "",
+				"CONJUR_SSL_PINS":                      "",
+				"CONJUR_SSL_PIN_ONLY":                  "",
 				"CONJUR_SSL_CERTIFICATE":               "testSSLCert",
 				"CONTAINER_MODE":                       "init",  // provided by annotation
 				"LOG_LEVEL":                            "debug", // provided by annotation
@@ -65,8 +78,25 @@ func TestGatherSettings(t *testing.T) {
 				"MY_POD_NAME":                          "testPodName",
 				"MY_POD_NAMESPACE":                     "testNameSpace",
 				"CONJUR_AUTHN_TOKEN_FILE":              k8s.DefaultTokenFilePath,
+				"CONJUR_CLIENT_CERT_CACHE_KEY_PATH":    "",
+				"CONJUR_CLIENT_CERT_CACHE_PATH":        "",
 				"CONJUR_CLIENT_CERT_PATH":              k8s.DefaultClientCertPath,
+				"CONJUR_CLIENT_CERT_RENEWAL_FRACTION":  k8s.DefaultClientCertRenewalFraction,
 				"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT": k8s.DefaultClientCertRetryCountLimit,
+				"CONJUR_CLIENT_KEY_ALGORITHM":          k8s.DefaultKeyAlgorithm,
+				"CONJUR_CLIENT_KEY_PROVIDER":           k8s.DefaultKeyProvider,
+				"CONJUR_PKCS11_MODULE":                 "",
+				"CONJUR_PKCS11_TOKEN_LABEL":            "",
+				"CONJUR_PKCS11_PIN_PATH":               "",
+				"CONJUR_POD_INFO_PATH":                 common.DefaultPodInfoPath,
+				"CONJUR_ANNOTATIONS_FILE":              "",
+				"CONJUR_CONFIG_FILE":                   "",
+				"CONJUR_STRICT_SETTINGS":               "",
+				"MY_POD_DEPLOYMENT":                    "",
+				"CONJUR_SPIFFE_TRUST_DOMAIN":           k8s.DefaultSPIFFETrustDomain,
+				"MY_POD_IP":                            "",
+				"MY_POD_SERVICE_ACCOUNT":               "",
+				"MY_POD_UID":                           "",
 				"CONJUR_TOKEN_TIMEOUT":                 k8s.DefaultTokenRefreshTimeout,
 			},
 		},
@@ -78,16 +108,45 @@ func TestGatherSettings(t *testing.T) {
 				"CONJUR_ACCOUNT":                       "testAccount",
 				"CONJUR_AUTHN_LOGIN":                   "host",
 				"CONJUR_CERT_FILE":                     "testSSLCertFile.txt",
+				"CONJUR_CA_SYSTEM_ROOTS":               "",
+				"CONJUR_CONNECT_TIMEOUT":               "10s",
+				"CONJUR_HTTP_HEADERS":                  "",
+				"CONJUR_HTTP_TIMEOUT":                  "10s",
+				"CONJUR_PROXY_CA_FILE":                 "",
+				"CONJUR_PROXY_URL":                     "",
+				"CONJUR_TLS_HANDSHAKE_TIMEOUT":         "10s",
+				"CONJUR_TLS_MIN_VERSION":               "1.2",
+				"CONJUR_TLS_SERVER_NAME":               "",
+				"CONJUR_SSL_BOOTSTRAP":                 "",
+				"CONJUR_SSL_PINS":                      "",
+				"CONJUR_SSL_PIN_ONLY":                  "",
 				"CONJUR_SSL_CERTIFICATE":               "testSSLCert",
 				"MY_POD_NAMESPACE":                     "testNameSpace",
 				"MY_POD_NAME":                          "testPodName",
 				"LOG_LEVEL":                            "",
 				"DEBUG":                                "",
 				"CONTAINER_MODE":                       "",
+				"CONJUR_CLIENT_CERT_CACHE_KEY_PATH":    "",
+				"CONJUR_CLIENT_CERT_CACHE_PATH":        "",
 				"CONJUR_CLIENT_CERT_PATH":              k8s.DefaultClientCertPath,
+				"CONJUR_CLIENT_CERT_RENEWAL_FRACTION":  k8s.DefaultClientCertRenewalFraction,
 				"CONJUR_AUTHN_TOKEN_FILE":              k8s.DefaultTokenFilePath,
 				"CONJUR_TOKEN_TIMEOUT":                 k8s.DefaultTokenRefreshTimeout,
 				"CONJUR_CLIENT_CERT_RETRY_COUNT_LIMIT": k8s.DefaultClientCertRetryCountLimit,
+				"CONJUR_CLIENT_KEY_ALGORITHM":          k8s.DefaultKeyAlgorithm,
+				"CONJUR_CLIENT_KEY_PROVIDER":           k8s.DefaultKeyProvider,
+				"CONJUR_PKCS11_MODULE":                 "",
+				"CONJUR_PKCS11_TOKEN_LABEL":            "",
+				"CONJUR_PKCS11_PIN_PATH":               "",
+				"CONJUR_POD_INFO_PATH":                 common.DefaultPodInfoPath,
+				"CONJUR_ANNOTATIONS_FILE":              "",
+				"CONJUR_CONFIG_FILE":                   "",
+				"CONJUR_STRICT_SETTINGS":               "",
+				"MY_POD_DEPLOYMENT":                    "",
+				"CONJUR_SPIFFE_TRUST_DOMAIN":           k8s.DefaultSPIFFETrustDomain,
+				"MY_POD_IP":                            "",
+				"MY_POD_SERVICE_ACCOUNT":               "",
+				"MY_POD_UID":                           "",
 			},
 		},
 	}
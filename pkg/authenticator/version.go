@@ -1,17 +1,11 @@
 package authenticator
 
-import "fmt"
-
-// Version field is a SemVer that should indicate the baked-in version
-// of the authn-k8s-client
-var Version = "unset"
-
-// TagSuffix field denotes the specific build type for the client. It may
-// be replaced by compile-time variables if needed to provide the git
-// commit information in the final binary.
-// In fixed versions, we don't want the tag to be present
-var TagSuffix = "-dev"
-
-// FullVersionName is the user-visible aggregation of version and tag
-// of this codebase
-var FullVersionName = fmt.Sprintf("v%s%s", Version, TagSuffix)
+import "github.com/cyberark/conjur-authn-k8s-client/pkg/version"
+
+// Version, TagSuffix and FullVersionName are those of the version package,
+// which is set at build time
+var (
+	Version         = version.Version
+	TagSuffix       = version.TagSuffix
+	FullVersionName = version.FullVersionName
+)
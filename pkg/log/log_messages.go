@@ -42,7 +42,7 @@ const CAKC026 string = "CAKC026 Failed to decode a DER encoded PKCS7 package. Re
 const CAKC027 string = "CAKC027 Failed to send https authenticate request or receive response. Reason: %s"
 const CAKC028 string = "CAKC028 Failed to send https login request or response. Reason: %s"
 const CAKC029 string = "CAKC029 Received invalid response to certificate signing request. Reason: %s"
-const CAKC030 string = "CAKC030 Failed to generate RSA keypair. Reason: %s"
+const CAKC030 string = "CAKC030 Failed to generate keypair. Reason: %s"
 const CAKC031 string = "CAKC031 Retransmission backoff exhausted"
 const CAKC032 string = "CAKC032 CONJUR_AUTHN_LOGIN %s must start with 'host/'"
 const CAKC033 string = "CAKC033 Timed out after waiting for %d seconds for file to exist: %s"
@@ -67,7 +67,7 @@ const CAKC051 string = "CAKC051 Waiting for file %s to become available..."
 const CAKC052 string = "CAKC052 Debug mode is enabled"
 const CAKC053 string = "CAKC053 Failed to read file %s"
 const CAKC054 string = "CAKC054 Failed to delete file %s"
-const CAKC055 string = "CAKC055 Cert placement failed with the following error:\n%s"
+const CAKC055 string = "CAKC055 Cert placement failed (%s) with the following error:\n%s"
 const CAKC056 string = "CAKC056 File %s does not exist"
 const CAKC057 string = "CAKC057 Removing file %s"
 const CAKC058 string = "CAKC058 Permissions error occured when checking if file exists: %s"
@@ -95,3 +95,74 @@ const CAKC079 string = "CAKC079 Extracted application identity (host) %s from co
 const CAKC080 string = "CAKC080 No application identity (host) detected in Authenticator configuration. Application identity will be taken from JWT provided in request."
 const CAKC081 string = "CAKC081 'DEBUG'/'conjur.org/debug-logging' is deprecated. Use 'LOG_LEVEL'/'conjur.org/log-level'='debug' instead."
 const CAKC082 string = "CAKC082 Compliance with FIPS 140-3 is not enabled"
+const CAKC083 string = "CAKC083 Failed to fetch JWT from token endpoint %s. Reason: %s"
+const CAKC084 string = "CAKC084 Failed to read OIDC client secret from %s"
+const CAKC085 string = "CAKC085 Failed to load OIDC client private key from %s. Reason: %s"
+const CAKC086 string = "CAKC086 Token endpoint response from %s does not contain a JWT"
+const CAKC087 string = "CAKC087 One of JWT_OIDC_CLIENT_SECRET_PATH and JWT_OIDC_PRIVATE_KEY_PATH must be provided"
+const CAKC088 string = "CAKC088 Fetching JWT from token endpoint %s..."
+const CAKC089 string = "CAKC089 Using cached JWT, expires: %v"
+const CAKC090 string = "CAKC090 Failed to decode JWT. Reason: %s"
+const CAKC091 string = "CAKC091 Requesting service account token for audience %s from %s..."
+const CAKC092 string = "CAKC092 Failed to request service account token from %s. Reason: %s"
+const CAKC093 string = "CAKC093 Failed to read service account credentials from %s. Reason: %s"
+const CAKC094 string = "CAKC094 Unable to determine the pod's service account. Set MY_POD_NAMESPACE and MY_POD_SERVICE_ACCOUNT"
+const CAKC095 string = "CAKC095 Fetching JWT-SVID for audience %s from the SPIFFE Workload API at %s..."
+const CAKC096 string = "CAKC096 Failed to fetch JWT-SVID from the SPIFFE Workload API at %s. Reason: %s"
+const CAKC097 string = "CAKC097 JWT supplied by the %q source"
+const CAKC098 string = "CAKC098 Failed to obtain JWT from the %q source, trying the next source"
+const CAKC099 string = "CAKC099 Failed to obtain JWT from any of the configured sources: %v"
+const CAKC100 string = "CAKC100 Environment variable %s does not contain a JWT"
+const CAKC101 string = "CAKC101 JWT command %q failed. Reason: %s"
+const CAKC102 string = "CAKC102 JWT command %q produced no output"
+const CAKC103 string = "CAKC103 JWT expired at %s (current time %s, allowed clock skew %s)"
+const CAKC104 string = "CAKC104 JWT is not valid before %s (current time %s, allowed clock skew %s)"
+const CAKC105 string = "CAKC105 JWT was issued in the future at %s (current time %s, allowed clock skew %s)"
+const CAKC106 string = "CAKC106 JWT issuer %q does not match the expected issuer %q"
+const CAKC107 string = "CAKC107 JWT audience %q does not include the expected audience %q"
+const CAKC108 string = "CAKC108 JWT does not contain the identity claim %q, which is required when CONJUR_AUTHN_LOGIN is not set"
+const CAKC109 string = "CAKC109 Failed to validate JWT before sending it to Conjur. Reason: %s"
+const CAKC111 string = "CAKC111 Generating %s private key..."
+const CAKC112 string = "CAKC112 Failed to generate certificate signing request. Reason: %s"
+const CAKC113 string = "CAKC113 Key algorithm %s is not supported by the %s key provider"
+const CAKC114 string = "CAKC114 Failed to load PKCS#11 module %s. Reason: %s"
+const CAKC115 string = "CAKC115 PKCS#11 token %q not found"
+const CAKC116 string = "CAKC116 Failed to read PKCS#11 PIN from %s. Reason: %s"
+const CAKC117 string = "CAKC117 Failed to generate %s private key in PKCS#11 token %q. Reason: %s"
+const CAKC118 string = "CAKC118 Generating non-extractable %s private key in PKCS#11 token %q..."
+const CAKC119 string = "CAKC119 Failed to log in to PKCS#11 token %q. Reason: %s"
+const CAKC120 string = "CAKC120 Creating mutual TLS client for the new client certificate"
+const CAKC121 string = "CAKC121 Renewing client certificate in the background, the current certificate expires at %s"
+const CAKC122 string = "CAKC122 Background renewal of the client certificate failed, retrying in %s. Reason: %s"
+const CAKC123 string = "CAKC123 Client certificate expires at %s and will be renewed at %s"
+const CAKC124 string = "CAKC124 Decrypting the encrypted access token with the client certificate key"
+const CAKC125 string = "CAKC125 Loaded cached client certificate from %s, it expires at %s"
+const CAKC126 string = "CAKC126 Ignoring the cached client certificate in %s. Reason: %s"
+const CAKC127 string = "CAKC127 Failed to save the client certificate to the cache %s. Reason: %s"
+const CAKC128 string = "CAKC128 Failed to read the client certificate cache key from %s. Reason: %s"
+const CAKC129 string = "CAKC129 The client certificate cache requires the %s key provider"
+const CAKC130 string = "CAKC130 Cannot watch for files to be written, polling instead. Reason: %s"
+const CAKC131 string = "CAKC131 Stopped waiting for file %s, an error was written to %s"
+const CAKC132 string = "CAKC132 Conjur failed to inject the client certificate, %s. %s"
+const CAKC133 string = "CAKC133 Placeholder %s in CONJUR_AUTHN_LOGIN could not be resolved from the pod metadata"
+const CAKC134 string = "CAKC134 Expanded CONJUR_AUTHN_LOGIN %s to %s"
+const CAKC135 string = "CAKC135 Failed to read configuration file %s. Reason: %s"
+const CAKC136 string = "CAKC136 Read settings from configuration file %s"
+const CAKC137 string = "CAKC137 Setting %s in %s is not used by the authenticator client"
+const CAKC138 string = "CAKC138 Failed to read pod annotations file %s. Reason: %s"
+const CAKC139 string = "CAKC139 Read settings from pod annotations file %s"
+const CAKC140 string = "CAKC140 Effective configuration:"
+const CAKC141 string = "CAKC141   %s=%s (%s)"
+const CAKC142 string = "CAKC142 Setting %s in %s is not used by the authenticator client, did you mean %s?"
+const CAKC143 string = "CAKC143 Failed to load the authenticator configuration. Reason: %s"
+const CAKC144 string = "CAKC144 Cannot read file %s, no file reader was given to the configuration builder"
+const CAKC145 string = "CAKC145 Invalid CA certificate file %s. Reason: %s"
+const CAKC146 string = "CAKC146 Loaded %d CA certificates from %s: %s"
+const CAKC147 string = "CAKC147 Failed to reload the CA certificates from %s, keeping the previous ones. Reason: %s"
+const CAKC148 string = "CAKC148 Trusting the system root CA certificates in addition to the Conjur CA certificates"
+const CAKC149 string = "CAKC149 Wrote the Conjur CA certificate %s to %s"
+const CAKC150 string = "CAKC150 Failed to bootstrap the Conjur CA certificate from %s. Reason: %s"
+const CAKC151 string = "CAKC151 The public keys of the Conjur server certificate chain %s do not match CONJUR_SSL_PINS"
+const CAKC152 string = "CAKC152 Failed to read the proxy CA certificates %s. Reason: %s"
+const CAKC153 string = "CAKC153 Failed to connect to the proxy %s. Reason: %s"
+const CAKC154 string = "CAKC154 Setting %s given invalid headers. Reason: %s"
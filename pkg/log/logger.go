@@ -33,29 +33,38 @@ var logLevel = "info"
 */
 func RecordedError(errorMessage string, args ...interface{}) error {
 	message := fmt.Sprintf(errorMessage, args...)
-	writeLog(ErrorLogger, "ERROR", message)
+	RequestLogger{}.write("ERROR", message)
 	return errors.New(message)
 }
 
 func Error(message string, args ...interface{}) {
-	writeLog(ErrorLogger, "ERROR", message, args...)
+	RequestLogger{}.write("ERROR", message, args...)
 }
 
 func Warn(message string, args ...interface{}) {
-	if logLevel == "debug" || logLevel == "info" || logLevel == "warn" {
-		writeLog(InfoLogger, "WARN", message, args...)
-	}
+	RequestLogger{}.write("WARN", message, args...)
 }
 
 func Info(message string, args ...interface{}) {
-	if logLevel == "debug" || logLevel == "info" {
-		writeLog(InfoLogger, "INFO", message, args...)
-	}
+	RequestLogger{}.write("INFO", message, args...)
 }
 
 func Debug(infoMessage string, args ...interface{}) {
-	if logLevel == "debug" {
-		writeLog(InfoLogger, "DEBUG", infoMessage, args...)
+	RequestLogger{}.write("DEBUG", infoMessage, args...)
+}
+
+// enabled returns true if messages of the given level are logged at the
+// current log level
+func enabled(level string) bool {
+	switch level {
+	case "DEBUG":
+		return logLevel == "debug"
+	case "INFO":
+		return logLevel == "debug" || logLevel == "info"
+	case "WARN":
+		return logLevel == "debug" || logLevel == "info" || logLevel == "warn"
+	default:
+		return true
 	}
 }
 
@@ -67,12 +76,11 @@ func SetLogLevel(level string) {
 	}
 }
 
-func writeLog(logger *log.Logger, logLevel string, message string, args ...interface{}) {
+func writeLog(logger *log.Logger, logLevel string, message string) {
 	// -7 format ensures logs alignment, by padding spaces to log level to ensure 7 characters length.
 	// 5 for longest log level, 1 for ':', and a space separator.
 	logger.SetPrefix(fmt.Sprintf("%-7s", logLevel+":"))
-	if len(args) > 0 {
-		message = fmt.Sprintf(message, args...)
-	}
-	logger.Output(3, message)
+	// Skip the frames of writeLog, RequestLogger.write and the exported
+	// logging function, to report the file and line of its caller
+	logger.Output(4, message)
 }
@@ -2,8 +2,10 @@ package log
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -58,3 +60,55 @@ func validateLog(t *testing.T, logFunc func(string, ...interface{}), logLevel, m
 	assert.Contains(t, logMessages, logLevel)
 	assert.Contains(t, logMessages, fmt.Sprintf(messageFormat, param))
 }
+
+func TestRequestLogger(t *testing.T) {
+	var logBuffer bytes.Buffer
+	ErrorLogger = log.New(&logBuffer, "", 0)
+	InfoLogger = log.New(&logBuffer, "", 0)
+
+	logger := FromContext(NewContext(context.Background(), WithRequestID("abc")))
+	assert.Equal(t, "abc", logger.RequestID())
+
+	logger.Info("message with param: <%s>", "100%")
+	assert.Contains(t, logBuffer.String(), "message with param: <100%> [request_id=abc]")
+
+	err := logger.RecordedError("failure: %s", "reason")
+	assert.EqualError(t, err, "failure: reason")
+	assert.Contains(t, logBuffer.String(), "failure: reason [request_id=abc]")
+
+	// Without a logger in the context, messages are not tagged
+	logBuffer.Reset()
+	FromContext(context.Background()).Error("message")
+	assert.Equal(t, "ERROR: message\n", logBuffer.String())
+}
+
+func TestLogLevels(t *testing.T) {
+	var logBuffer bytes.Buffer
+	ErrorLogger = log.New(&logBuffer, "", log.Lshortfile)
+	InfoLogger = log.New(&logBuffer, "", log.Lshortfile)
+	defer SetLogLevel("info")
+
+	// The package-level functions and the request loggers share the same
+	// level checks
+	SetLogLevel("warn")
+	Info("package info")
+	WithRequestID("abc").Info("request info")
+	Warn("package warn")
+	WithRequestID("abc").Warn("request warn")
+	assert.NotContains(t, logBuffer.String(), "info")
+	assert.Contains(t, logBuffer.String(), "package warn")
+	assert.Contains(t, logBuffer.String(), "request warn [request_id=abc]")
+
+	// Both report the file and line of their caller
+	logBuffer.Reset()
+	Warn("package warn")
+	WithRequestID("abc").Warn("request warn")
+	RecordedError("package error")
+	WithRequestID("abc").RecordedError("request error")
+	lines := strings.Split(strings.TrimSpace(logBuffer.String()), "\n")
+	if assert.Len(t, lines, 4) {
+		for _, line := range lines {
+			assert.Contains(t, line, "logger_test.go:")
+		}
+	}
+}
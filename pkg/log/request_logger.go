@@ -0,0 +1,93 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RequestLogger logs the messages of a single authentication attempt. Each
+// message is tagged with the ID sent to Conjur in the X-Request-Id header, so
+// that failures can be matched with the Conjur server logs.
+type RequestLogger struct {
+	requestID string
+}
+
+type requestLoggerKey struct{}
+
+// WithRequestID returns a logger tagging its messages with the given request ID
+func WithRequestID(requestID string) RequestLogger {
+	return RequestLogger{requestID: requestID}
+}
+
+// NewContext returns a copy of ctx carrying the given logger
+func NewContext(ctx context.Context, logger RequestLogger) context.Context {
+	return context.WithValue(ctx, requestLoggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx. Without one, messages are
+// logged without a request ID.
+func FromContext(ctx context.Context) RequestLogger {
+	logger, _ := ctx.Value(requestLoggerKey{}).(RequestLogger)
+	return logger
+}
+
+// RequestID returns the ID the messages are tagged with
+func (logger RequestLogger) RequestID() string {
+	return logger.requestID
+}
+
+// RecordedError logs the message like RecordedError. The returned error does
+// not include the request ID.
+func (logger RequestLogger) RecordedError(errorMessage string, args ...interface{}) error {
+	message := fmt.Sprintf(errorMessage, args...)
+	logger.write("ERROR", message)
+	return errors.New(message)
+}
+
+func (logger RequestLogger) Error(message string, args ...interface{}) {
+	logger.write("ERROR", message, args...)
+}
+
+func (logger RequestLogger) Warn(message string, args ...interface{}) {
+	logger.write("WARN", message, args...)
+}
+
+func (logger RequestLogger) Info(message string, args ...interface{}) {
+	logger.write("INFO", message, args...)
+}
+
+func (logger RequestLogger) Debug(message string, args ...interface{}) {
+	logger.write("DEBUG", message, args...)
+}
+
+// write logs the message at the given level, if the log level enables it.
+// The package-level logging functions log through it too, so both share the
+// same level checks. It must be called directly by the exported logging
+// functions, for the file and line of their caller to be logged.
+func (logger RequestLogger) write(level string, message string, args ...interface{}) {
+	if !enabled(level) {
+		return
+	}
+	target := InfoLogger
+	if level == "ERROR" {
+		target = ErrorLogger
+	}
+	writeLog(target, level, logger.format(message, args...))
+}
+
+func (logger RequestLogger) format(message string, args ...interface{}) string {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return logger.tag(message)
+}
+
+// tag appends the request ID to a formatted message. The message must not be
+// formatted again, as it may contain '%' characters.
+func (logger RequestLogger) tag(message string) string {
+	if logger.requestID == "" {
+		return message
+	}
+	return fmt.Sprintf("%s [request_id=%s]", message, logger.requestID)
+}
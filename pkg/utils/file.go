@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"os"
 	"time"
 
@@ -64,6 +65,88 @@ func waitForFile(
 	return nil
 }
 
+// fileWaitInterval is the interval at which waitForFileOrLog checks the files
+// when it cannot watch their directories. It matches the interval used by
+// WaitForFile, so that both wait for the same time.
+const fileWaitInterval = 50 * time.Millisecond
+
+// dirWatcher reports changes to files in a set of directories
+type dirWatcher interface {
+	// wait blocks until one of the watched files is written or moved into
+	// place, or until the timeout passes
+	wait(timeout time.Duration) error
+	close()
+}
+
+// WaitForFileOrLog waits for the file at path to exist, like WaitForFile, but
+// returns early with an error when the log file at logPath is written to
+// before the file exists. The directories of both files are watched for
+// changes where the platform supports it, and polled otherwise. The messages
+// are logged with the request logger of ctx.
+func WaitForFileOrLog(
+	ctx context.Context,
+	path string,
+	logPath string,
+	retryCountLimit int,
+) error {
+	return waitForFileOrLog(log.FromContext(ctx), path, logPath, retryCountLimit, osFileUtils, newDirWatcher)
+}
+
+func waitForFileOrLog(
+	logger log.RequestLogger,
+	path string,
+	logPath string,
+	retryCountLimit int,
+	utilities *fileUtils,
+	newWatcher func(paths []string) (dirWatcher, error),
+) error {
+	deadline := time.Now().Add(fileWaitInterval * time.Duration(retryCountLimit))
+
+	// Watch before the first check, so that no change is missed in between
+	paths := []string{path}
+	if logPath != "" {
+		paths = append(paths, logPath)
+	}
+	watcher, err := newWatcher(paths)
+	if err != nil {
+		logger.Debug(log.CAKC130, err)
+		watcher = nil
+	} else {
+		defer watcher.close()
+	}
+
+	for {
+		if verifyFileExists(path, utilities) == nil {
+			return nil
+		}
+		if logPath != "" && fileHasContent(logPath, utilities) {
+			return logger.RecordedError(log.CAKC131, path, logPath)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return logger.RecordedError(log.CAKC033, retryCountLimit, path)
+		}
+
+		logger.Debug(log.CAKC051, path)
+		if watcher != nil {
+			if err := watcher.wait(remaining); err != nil {
+				logger.Debug(log.CAKC130, err)
+				watcher.close()
+				watcher = nil
+			}
+			continue
+		}
+		time.Sleep(min(fileWaitInterval, remaining))
+	}
+}
+
+// fileHasContent returns true if a regular, non-empty file exists at path
+func fileHasContent(path string, utilities *fileUtils) bool {
+	info, err := utilities.stat(path)
+	return err == nil && info != nil && utilities.isRegular(info) && info.Size() > 0
+}
+
 // VerifyFileExists verifies that a file exists at a given path and is a
 // regular file.
 func VerifyFileExists(path string) error {
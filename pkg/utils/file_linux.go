@@ -0,0 +1,101 @@
+//go:build linux
+
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyWatcher watches directories with inotify and reports when one of the
+// given files is closed after writing or moved into place
+type inotifyWatcher struct {
+	fd    int
+	names map[string]bool
+}
+
+func newDirWatcher(paths []string) (dirWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &inotifyWatcher{fd: fd, names: map[string]bool{}}
+	watched := map[string]bool{}
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		watcher.names[filepath.Base(path)] = true
+		if watched[dir] {
+			continue
+		}
+		if _, err := unix.InotifyAddWatch(fd, dir, unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO); err != nil {
+			watcher.close()
+			return nil, err
+		}
+		watched[dir] = true
+	}
+	return watcher, nil
+}
+
+func (watcher *inotifyWatcher) wait(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	buffer := make([]byte, 4096)
+
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+
+		fds := []unix.PollFd{{Fd: int32(watcher.fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		length, err := unix.Read(watcher.fd, buffer)
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if watcher.matches(buffer[:length]) {
+			return nil
+		}
+	}
+}
+
+// matches returns true if one of the inotify events is about a watched file
+func (watcher *inotifyWatcher) matches(events []byte) bool {
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(events); {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&events[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		nameEnd := nameStart + int(event.Len)
+		if nameEnd > len(events) {
+			return false
+		}
+
+		name := string(bytes.TrimRight(events[nameStart:nameEnd], "\x00"))
+		if watcher.names[name] || event.Mask&unix.IN_Q_OVERFLOW != 0 {
+			return true
+		}
+		offset = nameEnd
+	}
+	return false
+}
+
+func (watcher *inotifyWatcher) close() {
+	unix.Close(watcher.fd)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package utils
+
+import "errors"
+
+func newDirWatcher(paths []string) (dirWatcher, error) {
+	return nil, errors.New("watching files is only supported on Linux")
+}
@@ -1,11 +1,16 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/cyberark/conjur-authn-k8s-client/pkg/log"
 )
 
 // Different types of file scenarios to test. (For file scenarios that use
@@ -165,3 +170,79 @@ func TestFile(t *testing.T) {
 		})
 	})
 }
+
+func TestWaitForFileOrLog(t *testing.T) {
+	watchers := map[string]func(paths []string) (dirWatcher, error){
+		"watching": newDirWatcher,
+		"polling": func(paths []string) (dirWatcher, error) {
+			return nil, errors.New("not supported")
+		},
+	}
+
+	for name, newWatcher := range watchers {
+		t.Run(name, func(t *testing.T) {
+			// writeLater writes content to path after a delay, moving it into place
+			// like Conjur does
+			writeLater := func(t *testing.T, path string, content string) {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					tmp := path + ".tmp"
+					if err := os.WriteFile(tmp, []byte(content), 0600); err == nil {
+						os.Rename(tmp, path)
+					}
+				}()
+			}
+
+			t.Run("Returns once the file is written", func(t *testing.T) {
+				dir := t.TempDir()
+				path := filepath.Join(dir, "client.pem")
+				logPath := filepath.Join(dir, "inject.log")
+				writeLater(t, path, "certificate")
+
+				start := time.Now()
+				err := waitForFileOrLog(log.RequestLogger{}, path, logPath, 100, osFileUtils, newWatcher)
+
+				assert.NoError(t, err)
+				assert.Less(t, time.Since(start), 2*time.Second)
+			})
+
+			t.Run("Returns an error once the log is written", func(t *testing.T) {
+				dir := t.TempDir()
+				path := filepath.Join(dir, "client.pem")
+				logPath := filepath.Join(dir, "inject.log")
+				writeLater(t, logPath, "error writing csr file\n")
+
+				start := time.Now()
+				err := waitForFileOrLog(log.RequestLogger{}, path, logPath, 100, osFileUtils, newWatcher)
+
+				assert.EqualError(t, err, fmt.Sprintf(
+					"CAKC131 Stopped waiting for file %s, an error was written to %s", path, logPath,
+				))
+				assert.Less(t, time.Since(start), 2*time.Second)
+			})
+
+			t.Run("Ignores an empty log", func(t *testing.T) {
+				dir := t.TempDir()
+				path := filepath.Join(dir, "client.pem")
+				logPath := filepath.Join(dir, "inject.log")
+				assert.NoError(t, os.WriteFile(logPath, nil, 0600))
+				writeLater(t, path, "certificate")
+
+				assert.NoError(t, waitForFileOrLog(log.RequestLogger{}, path, logPath, 100, osFileUtils, newWatcher))
+			})
+
+			t.Run("Times out if neither file is written", func(t *testing.T) {
+				dir := t.TempDir()
+				path := filepath.Join(dir, "client.pem")
+
+				start := time.Now()
+				err := waitForFileOrLog(log.RequestLogger{}, path, filepath.Join(dir, "inject.log"), 4, osFileUtils, newWatcher)
+
+				assert.EqualError(t, err, fmt.Sprintf(
+					"CAKC033 Timed out after waiting for %d seconds for file to exist: %s", 4, path,
+				))
+				assert.GreaterOrEqual(t, time.Since(start), 4*fileWaitInterval)
+			})
+		})
+	}
+}
@@ -0,0 +1,19 @@
+// Package version holds the version of the authn-k8s-client. It has no
+// dependencies, so that every package reporting the version can import it.
+package version
+
+import "fmt"
+
+// Version field is a SemVer that should indicate the baked-in version
+// of the authn-k8s-client
+var Version = "unset"
+
+// TagSuffix field denotes the specific build type for the client. It may
+// be replaced by compile-time variables if needed to provide the git
+// commit information in the final binary.
+// In fixed versions, we don't want the tag to be present
+var TagSuffix = "-dev"
+
+// FullVersionName is the user-visible aggregation of version and tag
+// of this codebase
+var FullVersionName = fmt.Sprintf("v%s%s", Version, TagSuffix)